@@ -0,0 +1,325 @@
+package gorbac
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrCasbinUnsupported occurred if a Casbin model or policy uses
+	// a feature goRBAC doesn't have
+	ErrCasbinUnsupported = errors.New("Casbin feature is not supported")
+)
+
+// CasbinMapping converts between permissions and the object and the
+// action of Casbin `p` lines.
+type CasbinMapping[T comparable] struct {
+	Import func(obj, act string) (Permission[T], error)
+	Export func(p Permission[T]) (obj, act string, err error)
+}
+
+var (
+	// CasbinStd maps `p, role, obj, act` to StdPermission `obj:act`.
+	CasbinStd = CasbinMapping[string]{
+		Import: func(obj, act string) (Permission[string], error) {
+			return NewPermission(obj + ":" + act), nil
+		},
+		Export: func(p Permission[string]) (string, string, error) {
+			if _, ok := p.(StdPermission[string]); !ok {
+				return "", "", fmt.Errorf("%w: %T", ErrUnsupportedPermission, p)
+			}
+			i := strings.LastIndex(p.ID(), ":")
+			if i < 0 {
+				return "", "", fmt.Errorf("%w: %q should be obj:act", ErrUnsupportedPermission, p.ID())
+			}
+			return p.ID()[:i], p.ID()[i+1:], nil
+		},
+	}
+	// CasbinLayer maps `p, role, /articles/*, edit` to LayerPermission
+	// `edit/articles` split by "/", which matches the sub-paths like
+	// `keyMatch`, and `p, role, /articles, edit` to StdPermission
+	// `edit/articles`, which matches the object only. Layers are exported
+	// with a trailing "/*", and every object with a leading "/".
+	//
+	// A layer also matches its own object: `/articles/*` grants
+	// `/articles`, which `keyMatch` doesn't. Grant the sub-paths of an
+	// object only, e.g. with CasbinResource, where that matters.
+	CasbinLayer = CasbinMapping[string]{
+		Import: func(obj, act string) (Permission[string], error) {
+			trimmed := strings.TrimSuffix(obj, "/*")
+			layer := trimmed != obj
+			obj = strings.Trim(trimmed, "/")
+			if strings.Contains(act, "/") || strings.Contains(obj, "*") {
+				return nil, fmt.Errorf("%w: pattern %s %s", ErrCasbinUnsupported, obj, act)
+			}
+			id := act
+			if obj != "" {
+				id += "/" + obj
+			}
+			if layer {
+				return NewLayerPermission(id, "/"), nil
+			}
+			return NewPermission(id), nil
+		},
+		Export: func(p Permission[string]) (string, string, error) {
+			switch q := p.(type) {
+			case LayerPermission:
+				if q.Sep != "/" {
+					break
+				}
+				act, obj, _ := strings.Cut(q.SID, "/")
+				if obj == "" {
+					return "/*", act, nil
+				}
+				return "/" + obj + "/*", act, nil
+			case StdPermission[string]:
+				act, obj, _ := strings.Cut(q.SID, "/")
+				return "/" + obj, act, nil
+			}
+			return "", "", fmt.Errorf("%w: %T", ErrUnsupportedPermission, p)
+		},
+	}
+	// CasbinResource maps `p, role, document:42, edit` to
+	// ResourcePermission `document:42:edit`. An object without ":" is
+	// a type of any resource, and "*" is ResourceAny.
+	CasbinResource = CasbinMapping[string]{
+		Import: func(obj, act string) (Permission[string], error) {
+			typ, resource, ok := strings.Cut(obj, ":")
+			if !ok {
+				resource = ResourceAny
+			}
+			return NewResourcePermission(typ, resource, act), nil
+		},
+		Export: func(p Permission[string]) (string, string, error) {
+			rp, ok := p.(ResourcePermission)
+			if !ok {
+				return "", "", fmt.Errorf("%w: %T", ErrUnsupportedPermission, p)
+			}
+			if rp.Resource == ResourceAny {
+				return rp.Type, rp.Action, nil
+			}
+			return rp.Type + ":" + rp.Resource, rp.Action, nil
+		},
+	}
+)
+
+// ImportCasbin builds an RBAC from a Casbin CSV policy: `p, role, obj,
+// act` lines assign the permission mapped by `m`, and `g, child, parent`
+// lines bind the parent. Lines are read as CSV records skipping the
+// spaces before each field, so a field with a comma, a quote or leading
+// spaces is quoted. Roles are decoded by the codec,
+// and added when they're first named. Domains, deny effects and other
+// policy types are refused with ErrCasbinUnsupported.
+func ImportCasbin[T comparable](r io.Reader, m CasbinMapping[T], codec IDCodec[T]) (*RBAC[T], error) {
+	rbac := New[T]()
+	role := func(line int, s string) (Role[T], error) {
+		id, err := codec.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if role, _, err := rbac.Get(id); err == nil {
+			return role, nil
+		}
+		role := NewRole(id)
+		rbac.Add(role)
+		return role, nil
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return rbac, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if (len(fields) == 1 && strings.TrimSpace(fields[0]) == "") || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch {
+		case fields[0] == "p" && (len(fields) == 4 || len(fields) == 5):
+			if len(fields) == 5 && fields[4] != "allow" {
+				return nil, fmt.Errorf("%w: line %d: effect %q", ErrCasbinUnsupported, line, fields[4])
+			}
+			p, err := m.Import(fields[2], fields[3])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			r, err := role(line, fields[1])
+			if err != nil {
+				return nil, err
+			}
+			if err := r.Assign(p); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		case fields[0] == "g" && len(fields) == 3:
+			child, err := role(line, fields[1])
+			if err != nil {
+				return nil, err
+			}
+			parent, err := role(line, fields[2])
+			if err != nil {
+				return nil, err
+			}
+			if err := rbac.SetParent(child.ID(), parent.ID()); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		case fields[0] == "p" || fields[0] == "g":
+			return nil, fmt.Errorf("%w: line %d: %d fields of %s, domains or extra fields", ErrCasbinUnsupported, line, len(fields), fields[0])
+		default:
+			return nil, fmt.Errorf("%w: line %d: policy type %q", ErrCasbinUnsupported, line, fields[0])
+		}
+	}
+}
+
+// ExportCasbin writes the roles and their parents as a Casbin CSV
+// policy, the roles encoded by the codec and the permissions mapped by
+// `m`, quoting the fields by encoding/csv when needed. Subjects,
+// constraints and limits aren't exported, and time-bounded grants or
+// edges are refused with ErrCasbinUnsupported.
+func ExportCasbin[T comparable](w io.Writer, rbac *RBAC[T], m CasbinMapping[T], codec IDCodec[T]) error {
+	rbac.mutex.RLock()
+	policies, groups, err := casbinLines(rbac, m, codec)
+	rbac.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, records := range [][][]string{policies, groups} {
+		sort.Slice(records, func(i, j int) bool {
+			return strings.Join(records[i], "\x00") < strings.Join(records[j], "\x00")
+		})
+		for _, record := range records {
+			if err := writeCasbinRecord(&b, record); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// writeCasbinRecord writes the fields separated by ", " as Casbin does,
+// each one quoted by encoding/csv when needed.
+func writeCasbinRecord(b *strings.Builder, record []string) error {
+	for i, field := range record {
+		var f strings.Builder
+		cw := csv.NewWriter(&f)
+		cw.Write([]string{field})
+		if cw.Flush(); cw.Error() != nil {
+			return cw.Error()
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strings.TrimSuffix(f.String(), "\n"))
+	}
+	b.WriteString("\n")
+	return nil
+}
+
+// casbinLines returns the `p` and `g` lines of the roles.
+func casbinLines[T comparable](rbac *RBAC[T], m CasbinMapping[T], codec IDCodec[T]) (policies, groups [][]string, err error) {
+	for _, e := range rbac.periods(func(Period) bool { return true }) {
+		if e.Kind != ExpiryAssignment {
+			return nil, nil, fmt.Errorf("%w: time-bounded %s %v of %v", ErrCasbinUnsupported, e.Kind, e.Target, e.ID)
+		}
+	}
+	for rid, role := range rbac.roles {
+		id, err := codec.Encode(rid)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range role.Permissions() {
+			obj, act, err := m.Export(p)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", id, err)
+			}
+			policies = append(policies, []string{"p", id, obj, act})
+		}
+		for rparent := range rbac.parents[rid] {
+			parent, err := codec.Encode(rparent)
+			if err != nil {
+				return nil, nil, err
+			}
+			groups = append(groups, []string{"g", id, parent})
+		}
+	}
+	return policies, groups, nil
+}
+
+// casbinMatchers are the matcher terms equivalent to IsGranted.
+var casbinMatchers = map[string]bool{
+	"g(r.sub,p.sub)":        true,
+	"r.obj==p.obj":          true,
+	"keyMatch(r.obj,p.obj)": true,
+	"r.act==p.act":          true,
+}
+
+// CheckCasbinModel checks that a Casbin model (model.conf) is the plain
+// RBAC model goRBAC can answer the same way: requests and policies of
+// `sub, obj, act`, one role definition without domains, the allow
+// effect, and a matcher joining g(r.sub, p.sub), equal objects or
+// keyMatch and equal actions by "&&". Anything else, e.g. custom
+// functions in the matcher, is reported with ErrCasbinUnsupported.
+// With CasbinLayer, keyMatch is answered the same way except that
+// `obj/*` also grants `obj` itself.
+func CheckCasbinModel(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	section := ""
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = text[1 : len(text)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return fmt.Errorf("%w: line %d: %q", ErrCasbinUnsupported, line, text)
+		}
+		key = strings.TrimSpace(key)
+		compact := strings.Join(strings.Fields(value), "")
+		unsupported := func(what string) error {
+			return fmt.Errorf("%w: line %d: %s %q", ErrCasbinUnsupported, line, what, strings.TrimSpace(value))
+		}
+		switch section {
+		case "request_definition":
+			if key != "r" || compact != "sub,obj,act" {
+				return unsupported("request")
+			}
+		case "policy_definition":
+			if key != "p" || (compact != "sub,obj,act" && compact != "sub,obj,act,eft") {
+				return unsupported("policy")
+			}
+		case "role_definition":
+			if key != "g" || compact != "_,_" {
+				return unsupported("role definition")
+			}
+		case "policy_effect":
+			if compact != "some(where(p.eft==allow))" {
+				return unsupported("effect")
+			}
+		case "matchers":
+			for _, term := range strings.Split(compact, "&&") {
+				if !casbinMatchers[term] {
+					return unsupported("matcher " + term + " in")
+				}
+			}
+		default:
+			return fmt.Errorf("%w: line %d: section %q", ErrCasbinUnsupported, line, section)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,181 @@
+package gorbac
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const casbinPolicy = `# roles
+p, reader, /articles/*, read
+p, editor, /articles/*, edit
+p, admin, /*, delete
+p, admin, /archive, read
+
+g, editor, reader
+g, admin, editor
+`
+
+func TestImportCasbin(t *testing.T) {
+	r, err := ImportCasbin(strings.NewReader(casbinPolicy), CasbinLayer, StringCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := map[string]bool{
+		"admin read/articles/42":  true,
+		"admin delete/anything":   true,
+		"admin read/archive":      true,
+		"admin read/archive/2024": false,
+		// wider than keyMatch("/articles", "/articles/*")
+		"reader read/articles":    true,
+		"editor edit/articles/42": true,
+		"editor delete/articles":  false,
+		"reader edit/articles/42": false,
+	}
+	for request, expected := range requests {
+		id, p, _ := strings.Cut(request, " ")
+		if r.IsGranted(id, NewLayerPermission(p, "/"), nil) != expected {
+			t.Fatalf("`%s` expected %v", request, expected)
+		}
+	}
+
+	var b strings.Builder
+	assert(t, ExportCasbin(&b, r, CasbinLayer, StringCodec{}))
+	expected := `p, admin, /*, delete
+p, admin, /archive, read
+p, editor, /articles/*, edit
+p, reader, /articles/*, read
+g, admin, editor
+g, editor, reader
+`
+	if b.String() != expected {
+		t.Fatalf("%q expected, but %q got", expected, b.String())
+	}
+	again, err := ImportCasbin(strings.NewReader(b.String()), CasbinLayer, StringCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ExportPolicy(r), ExportPolicy(again)) {
+		t.Fatalf("The policy expected to be imported again from\n%s", b.String())
+	}
+}
+
+func TestCasbinQuoting(t *testing.T) {
+	r := New[string]()
+	role := NewRole(`sales, "emea"`)
+	assert(t, role.Assign(NewPermission(`report, q1:read`)))
+	assert(t, r.Add(role))
+	assert(t, r.Add(NewRole(" lead")))
+	assert(t, r.SetParent(" lead", `sales, "emea"`))
+
+	var b strings.Builder
+	assert(t, ExportCasbin(&b, r, CasbinStd, StringCodec{}))
+	expected := `p, "sales, ""emea""", "report, q1", read
+g, " lead", "sales, ""emea"""
+`
+	if b.String() != expected {
+		t.Fatalf("%q expected, but %q got", expected, b.String())
+	}
+	again, err := ImportCasbin(strings.NewReader(b.String()), CasbinStd, StringCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.IsGranted(" lead", NewPermission(`report, q1:read`), nil) {
+		t.Fatalf("The policy expected to be imported again from\n%s", b.String())
+	}
+}
+
+func TestCasbinLayerShapes(t *testing.T) {
+	for obj, expected := range map[string]Permission[string]{
+		"/articles/*": NewLayerPermission("read/articles", "/"),
+		"/articles":   NewPermission("read/articles"),
+		"/*":          NewLayerPermission("read", "/"),
+		"/":           NewPermission("read"),
+	} {
+		p, err := CasbinLayer.Import(obj, "read")
+		if err != nil || p != expected {
+			t.Fatalf("%v expected for %s, but %v, %v got", expected, obj, p, err)
+		}
+		exported, act, err := CasbinLayer.Export(p)
+		if err != nil || exported != obj || act != "read" {
+			t.Fatalf("%s read expected, but %s %s, %v got", obj, exported, act, err)
+		}
+	}
+}
+
+func TestCasbinMappings(t *testing.T) {
+	for _, m := range []CasbinMapping[string]{CasbinStd, CasbinResource} {
+		r, err := ImportCasbin(strings.NewReader("p, editor, document:42, edit\np, editor, report, read\n"), m, StringCodec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		assert(t, ExportCasbin(&b, r, m, StringCodec{}))
+		if b.String() != "p, editor, document:42, edit\np, editor, report, read\n" {
+			t.Fatalf("The policy should be exported as it was, but %q got", b.String())
+		}
+	}
+	r, err := ImportCasbin(strings.NewReader("p, editor, document, edit\n"), CasbinResource, StringCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.IsGranted("editor", NewResourcePermission("document", "42", "edit"), nil) {
+		t.Fatal("A type without a resource should match any resource")
+	}
+	if err := ExportCasbin(&strings.Builder{}, r, CasbinStd, StringCodec{}); !errors.Is(err, ErrUnsupportedPermission) {
+		t.Fatalf("%s expected, but %v got", ErrUnsupportedPermission, err)
+	}
+}
+
+func TestCasbinUnsupported(t *testing.T) {
+	for _, policy := range []string{
+		"p, alice, domain1, data1, read, allow, extra\n",
+		"p, alice, data1, read, deny\n",
+		"g, alice, admin, domain1\n",
+		"g2, data1, group\n",
+		"p, alice, /data*, read\n",
+	} {
+		if _, err := ImportCasbin(strings.NewReader(policy), CasbinLayer, StringCodec{}); !errors.Is(err, ErrCasbinUnsupported) {
+			t.Fatalf("%s expected for %q, but %v got", ErrCasbinUnsupported, policy, err)
+		}
+	}
+
+	r := New[string]()
+	assert(t, r.Add(NewRole("contractor")))
+	assert(t, r.AssignWithin("contractor", NewPermission("deploy:run"), Period{NotAfter: r.clock()}))
+	if err := ExportCasbin(&strings.Builder{}, r, CasbinStd, StringCodec{}); !errors.Is(err, ErrCasbinUnsupported) {
+		t.Fatalf("%s expected, but %v got", ErrCasbinUnsupported, err)
+	}
+}
+
+func TestCheckCasbinModel(t *testing.T) {
+	model := `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act
+`
+	assert(t, CheckCasbinModel(strings.NewReader(model)))
+	for _, unsupported := range [][2]string{
+		{"g = _, _", "g = _, _, _"},
+		{"r = sub, obj, act", "r = sub, dom, obj, act"},
+		{"e = some(where (p.eft == allow))", "e = !some(where (p.eft == deny))"},
+		{"keyMatch(r.obj, p.obj)", "regexMatch(r.obj, p.obj)"},
+		{"&& r.act == p.act", "&& r.act == p.act || r.sub == \"root\""},
+	} {
+		m := strings.Replace(model, unsupported[0], unsupported[1], 1)
+		if err := CheckCasbinModel(strings.NewReader(m)); !errors.Is(err, ErrCasbinUnsupported) {
+			t.Fatalf("%s expected for %q, but %v got", ErrCasbinUnsupported, unsupported[1], err)
+		}
+	}
+}
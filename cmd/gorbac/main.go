@@ -0,0 +1,429 @@
+// Command gorbac inspects and checks a policy file.
+//
+// Usage:
+//
+//	gorbac [-f policy.json] <command> [arguments]
+//
+// The commands are:
+//
+//	check <role> <permission>     tests if the role is granted the permission
+//	explain <role> <permission>   shows the inheritance granting the permission
+//	who-can <permission>          lists the roles granted the permission
+//	effective <role>              lists the permissions of the role and its ancestors
+//	lint                          validates the policy
+//	cycles                        detects circle inheritance
+//	graph                         renders the role hierarchy
+//	diff <old.json> <new.json>    compares two policy files
+//	test <expectations.csv>       tests the policy against expectations
+//
+// A permission argument is a standard permission unless the flag -kind
+// of the command says layer, resource (`type:resource:action`), mask
+// (`resource:read|update`) or regex.
+//
+// A policy file ending with ".rbac" is read as the policy DSL, see
+// gorbac.ParseDSL, and JSON otherwise.
+//
+// The exit code is 0 on success or when the permission is granted, 1 when
+// it's denied, or lint, cycles, diff or test found anything, and 2 on
+// errors.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mikespook/gorbac/v3"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitFail  = 1
+	exitError = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// command runs with the arguments after its name, and returns the exit
+// code.
+type command func(c *cli, args []string) int
+
+var commands = map[string]command{
+	"check":     (*cli).check,
+	"explain":   (*cli).explain,
+	"who-can":   (*cli).whoCan,
+	"effective": (*cli).effective,
+	"lint":      (*cli).lint,
+	"cycles":    (*cli).cycles,
+	"graph":     (*cli).graph,
+	"diff":      (*cli).diff,
+	"test":      (*cli).test,
+}
+
+type cli struct {
+	policy string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr}
+	fs := flag.NewFlagSet("gorbac", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&c.policy, "f", "policy.json", "the policy `file`")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gorbac [-f policy.json] <command> [arguments]")
+		fmt.Fprintln(stderr, "commands: check, explain, who-can, effective, lint, cycles, graph, diff, test")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "gorbac: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return exitError
+	}
+	return cmd(c, fs.Args()[1:])
+}
+
+// flags returns a flag set of the command `name`.
+func (c *cli) flags(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: gorbac %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// permissionFlags parses the permission argument by the flags -kind
+// and -sep.
+func permissionFlags(fs *flag.FlagSet) func(string) (gorbac.Permission[string], error) {
+	kind := fs.String("kind", gorbac.PermissionStd, "the `kind` of permission: std, layer, resource, mask or regex")
+	sep := fs.String("sep", "/", "the `separator` of a layer permission")
+	return func(id string) (gorbac.Permission[string], error) {
+		return gorbac.ParsePermission(*kind, id, *sep)
+	}
+}
+
+// read reads the policy file `name`, in the policy DSL if it ends with
+// ".rbac" or else in JSON.
+func read(name string) (*gorbac.RBAC[string], error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p := &gorbac.Policy[string]{}
+	if strings.HasSuffix(name, ".rbac") {
+		p, err = gorbac.ParseDSL(f, gorbac.StringCodec{})
+	} else {
+		err = json.NewDecoder(f).Decode(p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	rbac, err := gorbac.ImportPolicy(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return rbac, nil
+}
+
+// load reads the policy file `name` like read, refusing a circle
+// inheritance to check permissions against.
+func load(name string) (*gorbac.RBAC[string], error) {
+	rbac, err := read(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := gorbac.InherCircle(rbac); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return rbac, nil
+}
+
+// prepare parses the flags of a command expecting `n` arguments and
+// loads the policy.
+func (c *cli) prepare(fs *flag.FlagSet, args []string, n int) (*gorbac.RBAC[string], bool) {
+	return c.prepareBy(load, fs, args, n)
+}
+
+// prepareBy is prepare reading the policy by `load`, e.g. read for the
+// commands inspecting a circle inheritance.
+func (c *cli) prepareBy(load func(string) (*gorbac.RBAC[string], error), fs *flag.FlagSet, args []string, n int) (*gorbac.RBAC[string], bool) {
+	if err := fs.Parse(args); err != nil {
+		return nil, false
+	}
+	if fs.NArg() != n {
+		fs.Usage()
+		return nil, false
+	}
+	rbac, err := load(c.policy)
+	if err != nil {
+		c.fail(err)
+		return nil, false
+	}
+	return rbac, true
+}
+
+func (c *cli) fail(err error) int {
+	fmt.Fprintf(c.stderr, "gorbac: %s\n", err)
+	return exitError
+}
+
+func (c *cli) encode(v any) int {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+func (c *cli) check(args []string) int {
+	fs := c.flags("check", "[-kind kind] <role> <permission>")
+	parse := permissionFlags(fs)
+	rbac, ok := c.prepare(fs, args, 2)
+	if !ok {
+		return exitError
+	}
+	p, err := parse(fs.Arg(1))
+	if err != nil {
+		return c.fail(err)
+	}
+	if _, _, err := rbac.Get(fs.Arg(0)); err != nil {
+		return c.fail(err)
+	}
+	if rbac.IsGranted(fs.Arg(0), p, nil) {
+		fmt.Fprintln(c.stdout, "granted")
+		return exitOK
+	}
+	fmt.Fprintln(c.stdout, "denied")
+	return exitFail
+}
+
+func (c *cli) explain(args []string) int {
+	fs := c.flags("explain", "[-kind kind] [-json] <role> <permission>")
+	parse := permissionFlags(fs)
+	asJSON := fs.Bool("json", false, "print as JSON")
+	rbac, ok := c.prepare(fs, args, 2)
+	if !ok {
+		return exitError
+	}
+	p, err := parse(fs.Arg(1))
+	if err != nil {
+		return c.fail(err)
+	}
+	e, err := gorbac.Explain(rbac, fs.Arg(0), p)
+	if err != nil {
+		return c.fail(err)
+	}
+	code := exitOK
+	if !e.Granted {
+		code = exitFail
+	}
+	if *asJSON {
+		if c.encode(e) != exitOK {
+			return exitError
+		}
+		return code
+	}
+	if e.Granted {
+		fmt.Fprintf(c.stdout, "granted: %s (%s)\n", strings.Join(e.Path, " -> "), e.Grant)
+	} else {
+		fmt.Fprintln(c.stdout, "denied")
+	}
+	return code
+}
+
+func (c *cli) whoCan(args []string) int {
+	fs := c.flags("who-can", "[-kind kind] <permission>")
+	parse := permissionFlags(fs)
+	rbac, ok := c.prepare(fs, args, 1)
+	if !ok {
+		return exitError
+	}
+	p, err := parse(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+	roles := gorbac.WhoCan(rbac, p)
+	for _, id := range roles {
+		fmt.Fprintln(c.stdout, id)
+	}
+	if len(roles) == 0 {
+		return exitFail
+	}
+	return exitOK
+}
+
+func (c *cli) effective(args []string) int {
+	fs := c.flags("effective", "[-json] <role>")
+	asJSON := fs.Bool("json", false, "print as JSON")
+	rbac, ok := c.prepare(fs, args, 1)
+	if !ok {
+		return exitError
+	}
+	permissions, err := gorbac.Effective(rbac, fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+	if *asJSON {
+		result := make([]gorbac.PolicyPermission[string], len(permissions))
+		for i, p := range permissions {
+			result[i] = gorbac.PolicyPermission[string]{Permission: p}
+		}
+		return c.encode(result)
+	}
+	for _, p := range permissions {
+		fmt.Fprintln(c.stdout, p.ID())
+	}
+	return exitOK
+}
+
+func (c *cli) lint(args []string) int {
+	fs := c.flags("lint", "[-json] [-fail severity]")
+	asJSON := fs.Bool("json", false, "print as JSON")
+	threshold := gorbac.SeverityError
+	fs.TextVar(&threshold, "fail", gorbac.SeverityError, "fail on findings of this `severity` or higher")
+	rbac, ok := c.prepareBy(read, fs, args, 0)
+	if !ok {
+		return exitError
+	}
+	findings := gorbac.Validate(rbac)
+	if *asJSON {
+		if findings == nil {
+			findings = []gorbac.Finding[string]{}
+		}
+		if c.encode(findings) != exitOK {
+			return exitError
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Fprintf(c.stdout, "%s\t%s\t%s\t%s\n", f.Severity, f.Code, f.Role, f.Message)
+		}
+	}
+	if len(findings) > 0 && gorbac.MaxSeverity(findings) >= threshold {
+		return exitFail
+	}
+	return exitOK
+}
+
+func (c *cli) cycles(args []string) int {
+	fs := c.flags("cycles", "")
+	rbac, ok := c.prepareBy(read, fs, args, 0)
+	if !ok {
+		return exitError
+	}
+	var cycle *gorbac.CycleError[string]
+	if err := gorbac.InherCircle(rbac); errors.As(err, &cycle) {
+		fmt.Fprintln(c.stdout, strings.Join(cycle.Path, " -> "))
+		return exitFail
+	}
+	return exitOK
+}
+
+func (c *cli) graph(args []string) int {
+	fs := c.flags("graph", "[-format dot|mermaid] [-permissions] [-focus role,...]")
+	format := fs.String("format", "dot", "the output `format`: dot or mermaid")
+	permissions := fs.Bool("permissions", false, "render permissions as leaf nodes")
+	focus := fs.String("focus", "", "render only the comma separated `roles` and their relatives")
+	rbac, ok := c.prepareBy(read, fs, args, 0)
+	if !ok {
+		return exitError
+	}
+	opts := gorbac.ExportOptions[string]{Permissions: *permissions, Codec: gorbac.StringCodec{}}
+	if *focus != "" {
+		opts.Focus = strings.Split(*focus, ",")
+	}
+	var err error
+	switch *format {
+	case "dot":
+		err = gorbac.WriteDOT(c.stdout, rbac, opts)
+	case "mermaid":
+		err = gorbac.WriteMermaid(c.stdout, rbac, opts)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+func (c *cli) diff(args []string) int {
+	fs := c.flags("diff", "[-json] <old.json> <new.json>")
+	asJSON := fs.Bool("json", false, "print as JSON")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+	old, err := load(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+	new, err := load(fs.Arg(1))
+	if err != nil {
+		return c.fail(err)
+	}
+	d := gorbac.Diff(old, new)
+	if *asJSON {
+		if c.encode(d) != exitOK {
+			return exitError
+		}
+	} else if err := d.Format(c.stdout, gorbac.StringCodec{}); err != nil {
+		return c.fail(err)
+	}
+	if d.IsZero() {
+		return exitOK
+	}
+	return exitFail
+}
+
+func (c *cli) test(args []string) int {
+	fs := c.flags("test", "<expectations.csv>")
+	rbac, ok := c.prepare(fs, args, 1)
+	if !ok {
+		return exitError
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+	defer f.Close()
+	expectations, err := gorbac.ParseExpectations(f, gorbac.StringCodec{})
+	if err != nil {
+		return c.fail(fmt.Errorf("%s: %w", fs.Arg(0), err))
+	}
+	for _, e := range expectations {
+		if len(e.Attributes) > 0 {
+			fmt.Fprintf(c.stderr, "gorbac: %s: line %d: assertions are not evaluated by the command\n", fs.Arg(0), e.Line)
+		}
+	}
+	mismatches := gorbac.CheckExpectations(rbac, expectations, nil)
+	for _, m := range mismatches {
+		fmt.Fprintf(c.stdout, "%s: %s\n", fs.Arg(0), m)
+	}
+	if len(mismatches) > 0 {
+		return exitFail
+	}
+	return exitOK
+}
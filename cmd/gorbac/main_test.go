@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const policy = `{
+	"roles": {
+		"chief-editor": {"permissions": ["del-text"], "parents": ["editor", "photographer"]},
+		"editor": {"permissions": ["add-text", {"kind": "layer", "id": "articles/edit", "sep": "/"}]},
+		"photographer": {"permissions": ["add-photo"]},
+		"intern": {}
+	}
+}`
+
+func write(t *testing.T, name, data string) string {
+	name = filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestRun(t *testing.T) {
+	file := write(t, "policy.json", policy)
+	changed := write(t, "changed.json", strings.Replace(policy, `"del-text"`, `"del-photo"`, 1))
+	cycle := write(t, "cycle.json", strings.Replace(policy, `"permissions": ["add-photo"]`, `"parents": ["chief-editor"]`, 1))
+	dsl := write(t, "policy.rbac", "role editor { grant add-text }\nrole chief-editor extends editor\n")
+	broken := write(t, "broken.rbac", "role editor {\n\tdeny add-text\n}\n")
+	expectations := write(t, "expectations.csv", "chief-editor, add-text, allow\neditor, add-text, deny\n")
+	cases := []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{"-f", file, "check", "chief-editor", "add-text"}, exitOK, "granted\n"},
+		{[]string{"-f", file, "check", "editor", "add-photo"}, exitFail, "denied\n"},
+		{[]string{"-f", file, "check", "-kind", "layer", "chief-editor", "articles/edit/1"}, exitOK, "granted\n"},
+		{[]string{"-f", file, "check", "nobody", "add-text"}, exitError, ""},
+		{[]string{"-f", file, "explain", "chief-editor", "add-photo"}, exitOK, "granted: chief-editor -> photographer (add-photo)\n"},
+		{[]string{"-f", file, "who-can", "add-text"}, exitOK, "chief-editor\neditor\n"},
+		{[]string{"-f", file, "who-can", "unknown"}, exitFail, ""},
+		{[]string{"-f", file, "effective", "chief-editor"}, exitOK, "add-photo\nadd-text\narticles/edit\ndel-text\n"},
+		{[]string{"-f", file, "lint"}, exitOK, "warning\tempty-role\tintern\tintern has neither permissions nor parents\n"},
+		{[]string{"-f", file, "lint", "-fail", "warning"}, exitFail, "warning\tempty-role\tintern\tintern has neither permissions nor parents\n"},
+		{[]string{"-f", file, "cycles"}, exitOK, ""},
+		{[]string{"-f", cycle, "cycles"}, exitFail, ""},
+		{[]string{"-f", cycle, "check", "chief-editor", "add-text"}, exitError, ""},
+		{[]string{"-f", cycle, "who-can", "add-text"}, exitError, ""},
+		{[]string{"diff", file, cycle}, exitError, ""},
+		{[]string{"-f", file, "graph", "--format", "mermaid", "-focus", "intern"}, exitOK, "flowchart BT\n\tr0([\"intern\"])\n"},
+		{[]string{"-f", dsl, "check", "chief-editor", "add-text"}, exitOK, "granted\n"},
+		{[]string{"-f", broken, "lint"}, exitError, ""},
+		{[]string{"diff", file, file}, exitOK, ""},
+		{[]string{"diff", file, changed}, exitFail, "+ grant chief-editor del-photo\n- grant chief-editor del-text\ngranted chief-editor del-photo\ndenied chief-editor del-text\n"},
+		{[]string{"-f", filepath.Join(t.TempDir(), "missing.json"), "lint"}, exitError, ""},
+		{[]string{"-f", file, "test", expectations}, exitFail,
+			expectations + ": line 2: editor add-text expected deny, but allow: granted by editor (add-text)\n"},
+		{[]string{"unknown"}, exitError, ""},
+		{[]string{}, exitError, ""},
+	}
+	for _, c := range cases {
+		var stdout, stderr strings.Builder
+		code := run(c.args, &stdout, &stderr)
+		if code != c.code {
+			t.Fatalf("%v: exit code %d expected, but %d got: %s", c.args, c.code, code, stderr.String())
+		}
+		if code == exitFail && c.args[len(c.args)-1] == "cycles" {
+			// the circle may start from any role on it
+			if !strings.Contains(stdout.String(), "photographer -> chief-editor") {
+				t.Fatalf("%v: the circle expected, but %q got", c.args, stdout.String())
+			}
+			continue
+		}
+		if stdout.String() != c.output {
+			t.Fatalf("%v: %q expected, but %q got", c.args, c.output, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package gorbac
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var (
+	// ErrInvalidID occurred if an ID can't be decoded by an IDCodec
+	ErrInvalidID = errors.New("Invalid ID")
+)
+
+// IDCodec converts the IDs of roles, subjects and permissions to and
+// from strings, so RBAC[T] of any T can be serialised.
+type IDCodec[T comparable] interface {
+	Encode(id T) (string, error)
+	Decode(s string) (T, error)
+}
+
+// StringCodec is the IDCodec of string IDs, keeping them as they are.
+type StringCodec struct{}
+
+// Encode returns the ID
+func (StringCodec) Encode(id string) (string, error) {
+	return id, nil
+}
+
+// Decode returns the string
+func (StringCodec) Decode(s string) (string, error) {
+	return s, nil
+}
+
+// Integer is any integer type
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// IntCodec is the IDCodec of integer IDs in decimal.
+type IntCodec[T Integer] struct{}
+
+// Encode formats the ID in decimal
+func (IntCodec[T]) Encode(id T) (string, error) {
+	if id < 0 {
+		return strconv.FormatInt(int64(id), 10), nil
+	}
+	return strconv.FormatUint(uint64(id), 10), nil
+}
+
+// Decode parses a decimal ID, which has to fit in T.
+func (IntCodec[T]) Decode(s string) (T, error) {
+	var zero T
+	if signed := zero-1 < zero; signed {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || int64(T(n)) != n {
+			return zero, fmt.Errorf("%w: %q should be an integer of %T", ErrInvalidID, s, zero)
+		}
+		return T(n), nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || uint64(T(n)) != n {
+		return zero, fmt.Errorf("%w: %q should be an integer of %T", ErrInvalidID, s, zero)
+	}
+	return T(n), nil
+}
+
+// TextCodec is the IDCodec of IDs implementing encoding.TextMarshaler,
+// whose pointers implement encoding.TextUnmarshaler, e.g.
+// `TextCodec[RoleID, *RoleID]{}`.
+type TextCodec[T interface {
+	comparable
+	encoding.TextMarshaler
+}, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}] struct{}
+
+// Encode marshals the ID by MarshalText
+func (TextCodec[T, PT]) Encode(id T) (string, error) {
+	data, err := id.MarshalText()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidID, err)
+	}
+	return string(data), nil
+}
+
+// Decode unmarshals the ID by UnmarshalText
+func (TextCodec[T, PT]) Decode(s string) (T, error) {
+	var id T
+	if err := PT(&id).UnmarshalText([]byte(s)); err != nil {
+		return id, fmt.Errorf("%w: %w", ErrInvalidID, err)
+	}
+	return id, nil
+}
+
+// encodeID encodes the ID by the codec, or by fmt.Sprint if it's nil.
+func encodeID[T comparable](codec IDCodec[T], id T) (string, error) {
+	if codec == nil {
+		return fmt.Sprint(id), nil
+	}
+	return codec.Encode(id)
+}
+
+// DecodePermission decodes a permission of `kind` like ParsePermission,
+// the ID of a StdPermission by the codec. The other kinds have string
+// IDs, so they're supported only if T is string.
+func DecodePermission[T comparable](codec IDCodec[T], kind, id, sep string) (Permission[T], error) {
+	if kind == "" || kind == PermissionStd {
+		pid, err := codec.Decode(id)
+		if err != nil {
+			return nil, err
+		}
+		return NewPermission(pid), nil
+	}
+	p, err := ParsePermission(kind, id, sep)
+	if err != nil {
+		return nil, err
+	}
+	q, ok := p.(Permission[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: kind %q needs string IDs", ErrUnsupportedPermission, kind)
+	}
+	return q, nil
+}
+
+// EncodePermission returns the kind, the ID and the separator of a
+// built-in permission as read by DecodePermission. A MaskPermission is
+// encoded as `resource:read|update`, and the separator is empty unless
+// it's a LayerPermission.
+func EncodePermission[T comparable](codec IDCodec[T], p Permission[T]) (kind, id, sep string, err error) {
+	if kind, err = permissionKind(p); err != nil {
+		return "", "", "", err
+	}
+	switch q := any(p).(type) {
+	case StdPermission[T]:
+		id, err = codec.Encode(q.SID)
+		return kind, id, "", err
+	case LayerPermission:
+		return kind, q.SID, q.Sep, nil
+	case MaskPermission:
+		actions, err := q.Actions.MarshalText()
+		if err != nil {
+			return "", "", "", err
+		}
+		return kind, q.SID + ":" + string(actions), "", nil
+	}
+	return kind, fmt.Sprint(p.ID()), "", nil
+}
+
+// EncodePolicy converts the IDs of a policy to strings by the codec,
+// e.g. to write a policy of struct IDs as JSON or by FormatDSL.
+func EncodePolicy[T comparable](p *Policy[T], codec IDCodec[T]) (*Policy[string], error) {
+	return convertPolicy(p, codec.Encode, func(q Permission[T]) (Permission[string], error) {
+		kind, id, sep, err := EncodePermission(codec, q)
+		if err != nil {
+			return nil, err
+		}
+		return ParsePermission(kind, id, sep)
+	})
+}
+
+// DecodePolicy converts the string IDs of a policy by the codec.
+func DecodePolicy[T comparable](p *Policy[string], codec IDCodec[T]) (*Policy[T], error) {
+	return convertPolicy(p, codec.Decode, func(q Permission[string]) (Permission[T], error) {
+		kind, id, sep, err := EncodePermission[string](StringCodec{}, q)
+		if err != nil {
+			return nil, err
+		}
+		return DecodePermission(codec, kind, id, sep)
+	})
+}
+
+// convertPolicy converts the IDs of a policy from S to T.
+func convertPolicy[S, T comparable](p *Policy[S], id func(S) (T, error), permission func(Permission[S]) (Permission[T], error)) (*Policy[T], error) {
+	ids := func(s []S) ([]T, error) {
+		if s == nil {
+			return nil, nil
+		}
+		result := make([]T, len(s))
+		for i, v := range s {
+			var err error
+			if result[i], err = id(v); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	result := &Policy[T]{Roles: make(map[T]PolicyRole[T], len(p.Roles))}
+	for rid, pr := range p.Roles {
+		k, err := id(rid)
+		if err != nil {
+			return nil, err
+		}
+		r := PolicyRole[T]{Metadata: pr.Metadata}
+		if r.Parents, err = ids(pr.Parents); err != nil {
+			return nil, err
+		}
+		for _, q := range pr.Permissions {
+			converted, err := permission(q.Permission)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", rid, err)
+			}
+			r.Permissions = append(r.Permissions, PolicyPermission[T]{converted})
+		}
+		result.Roles[k] = r
+	}
+	for subject, roles := range p.Subjects {
+		if result.Subjects == nil {
+			result.Subjects = make(map[T][]T, len(p.Subjects))
+		}
+		k, err := id(subject)
+		if err != nil {
+			return nil, err
+		}
+		if result.Subjects[k], err = ids(roles); err != nil {
+			return nil, err
+		}
+	}
+	for _, sod := range []struct {
+		from []SoDConstraint[S]
+		to   *[]SoDConstraint[T]
+	}{{p.SSD, &result.SSD}, {p.DSD, &result.DSD}} {
+		for _, c := range sod.from {
+			roles, err := ids(c.Roles)
+			if err != nil {
+				return nil, err
+			}
+			*sod.to = append(*sod.to, SoDConstraint[T]{c.Name, roles, c.Cardinality})
+		}
+	}
+	for rid, l := range p.Limits {
+		if result.Limits == nil {
+			result.Limits = make(map[T]Limit, len(p.Limits))
+		}
+		k, err := id(rid)
+		if err != nil {
+			return nil, err
+		}
+		result.Limits[k] = l
+	}
+	for _, e := range p.Periods {
+		k, err := id(e.ID)
+		if err != nil {
+			return nil, err
+		}
+		target, err := id(e.Target)
+		if err != nil {
+			return nil, err
+		}
+		result.Periods = append(result.Periods, Expiry[T]{e.Kind, k, target, e.Period})
+	}
+	result.normalize()
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roleID is an ID of a role in a tenant
+type roleID struct {
+	Tenant int
+	Name   string
+}
+
+func (id roleID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d/%s", id.Tenant, id.Name)), nil
+}
+
+func (id *roleID) UnmarshalText(data []byte) error {
+	tenant, name, ok := strings.Cut(string(data), "/")
+	if !ok {
+		return fmt.Errorf("%q should be tenant/name", data)
+	}
+	_, err := fmt.Sscan(tenant, &id.Tenant)
+	id.Name = name
+	return err
+}
+
+func TestIntCodec(t *testing.T) {
+	if s, err := (IntCodec[int64]{}).Encode(-42); err != nil || s != "-42" {
+		t.Fatalf("-42 expected, but %q, %v got", s, err)
+	}
+	if id, err := (IntCodec[uint16]{}).Decode("65535"); err != nil || id != 65535 {
+		t.Fatalf("65535 expected, but %d, %v got", id, err)
+	}
+	for _, s := range []string{"128", "-129", "x", ""} {
+		if _, err := (IntCodec[int8]{}).Decode(s); !errors.Is(err, ErrInvalidID) {
+			t.Fatalf("%s expected for %q, but %v got", ErrInvalidID, s, err)
+		}
+	}
+	if _, err := (IntCodec[uint]{}).Decode("-1"); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("%s expected, but %v got", ErrInvalidID, err)
+	}
+}
+
+func TestTextCodec(t *testing.T) {
+	codec := TextCodec[roleID, *roleID]{}
+	r := New[roleID]()
+	editor, reader := roleID{1, "editor"}, roleID{1, "reader"}
+	role := NewRole(reader)
+	assert(t, role.Assign(NewPermission(roleID{0, "read"})))
+	assert(t, r.Add(NewRole(editor)))
+	assert(t, r.Add(role))
+	assert(t, r.SetParent(editor, reader))
+	assert(t, r.AssignRole(roleID{1, "alice"}, editor))
+
+	encoded, err := EncodePolicy(ExportPolicy(r), codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := encoded.Roles["1/editor"]; !ok {
+		t.Fatalf("1/editor expected, but %v got", encoded.Roles)
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Policy[string]
+	assert(t, json.Unmarshal(data, &p))
+	decoded, err := DecodePolicy(&p, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ExportPolicy(r), decoded) {
+		t.Fatalf("The policy expected to be decoded from %s", data)
+	}
+
+	var b strings.Builder
+	assert(t, FormatDSL(&b, decoded, codec))
+	parsed, err := ParseDSL(strings.NewReader(b.String()), codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ImportPolicy(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.IsGranted(editor, NewPermission(roleID{0, "read"}), nil) {
+		t.Fatalf("[0/read] should be granted by\n%s", b.String())
+	}
+	var e *DSLError
+	if _, err := ParseDSL(strings.NewReader("role 1/editor extends reader"), codec); !errors.As(err, &e) || e.Column != 23 || !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("%s at 1:23 expected, but %v got", ErrInvalidID, err)
+	}
+}
+
+func TestDecodePermission(t *testing.T) {
+	p, err := DecodePermission[int](IntCodec[int]{}, PermissionStd, "42", "")
+	if err != nil || p != NewPermission(42) {
+		t.Fatalf("[42] expected, but %v, %v got", p, err)
+	}
+	if _, err := DecodePermission[int](IntCodec[int]{}, PermissionLayer, "a/b", "/"); !errors.Is(err, ErrUnsupportedPermission) {
+		t.Fatalf("%s expected, but %v got", ErrUnsupportedPermission, err)
+	}
+	kind, id, sep, err := EncodePermission[string](StringCodec{}, NewLayerPermission("a::b", "::"))
+	if err != nil || kind != PermissionLayer || id != "a::b" || sep != "::" {
+		t.Fatalf("layer a::b :: expected, but %s %s %s %v got", kind, id, sep, err)
+	}
+	q, err := DecodePermission[string](StringCodec{}, kind, id, sep)
+	if err != nil || q != NewLayerPermission("a::b", "::") {
+		t.Fatalf("[a::b] expected, but %v, %v got", q, err)
+	}
+}
+
+func TestCodecRenderers(t *testing.T) {
+	codec := TextCodec[roleID, *roleID]{}
+	r := New[roleID]()
+	editor, reader := roleID{1, "editor"}, roleID{1, "reader"}
+	assert(t, r.Add(NewRole(editor)))
+	assert(t, r.Add(NewRole(reader)))
+	assert(t, r.SetParent(editor, reader))
+
+	var b strings.Builder
+	assert(t, WriteDOT(&b, r, ExportOptions[roleID]{Codec: codec}))
+	if !strings.Contains(b.String(), `"role:1/editor" -> "role:1/reader";`) {
+		t.Fatalf("The IDs expected to be encoded, but\n%s got", b.String())
+	}
+	b.Reset()
+	assert(t, WriteMermaid(&b, r, ExportOptions[roleID]{Codec: codec}))
+	if !strings.Contains(b.String(), `r0(["1/editor"])`) {
+		t.Fatalf("The IDs expected to be encoded, but\n%s got", b.String())
+	}
+
+	d := &PolicyDiff[roleID]{AddedEdges: []Edge[roleID]{{editor, reader}}}
+	b.Reset()
+	assert(t, d.Format(&b, codec))
+	if b.String() != "+ parent 1/editor -> 1/reader\n" {
+		t.Fatalf("The IDs expected to be encoded, but %q got", b.String())
+	}
+}
@@ -0,0 +1,284 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrSSDViolation occurred if a static separation of duty
+	// constraint would be broken
+	ErrSSDViolation = errors.New("Static separation of duty violated")
+	// ErrDSDViolation occurred if a dynamic separation of duty
+	// constraint would be broken
+	ErrDSDViolation = errors.New("Dynamic separation of duty violated")
+	// ErrConstraintExist occurred if a constraint shouldn't be found
+	ErrConstraintExist = errors.New("Constraint has already existed")
+	// ErrConstraintNotExist occurred if a constraint cann't be found
+	ErrConstraintNotExist = errors.New("Constraint does not exist")
+	// ErrInvalidConstraint occurred if a constraint can never be satisfied
+	// or never be broken
+	ErrInvalidConstraint = errors.New("Invalid constraint")
+)
+
+// SoDConstraint declares a set of mutually exclusive roles.
+// Nobody may hold `Cardinality` or more roles of the set at once,
+// roles inherited from parents included.
+type SoDConstraint[T comparable] struct {
+	Name        string `json:"name"`
+	Roles       []T    `json:"roles"`
+	Cardinality int    `json:"cardinality"`
+}
+
+// SoDError describes which constraint was broken and by which roles.
+type SoDError[T comparable] struct {
+	// Err is the sentinel error of the violation, e.g. ErrSSDViolation
+	Err error
+	// Constraint is the name of the broken constraint
+	Constraint string
+	// ID is the role or subject holding the conflicting roles
+	ID T
+	// Roles are the conflicting roles
+	Roles []T
+}
+
+func (e *SoDError[T]) Error() string {
+	return fmt.Sprintf("%s: %v holds %v of constraint %q",
+		e.Err, e.ID, e.Roles, e.Constraint)
+}
+
+func (e *SoDError[T]) Unwrap() error {
+	return e.Err
+}
+
+// AddSSD registers a static separation of duty constraint.
+// All roles of the constraint must exist and be distinct, and neither
+// roles nor subjects may break it at the time of registration. The
+// constraint is copied.
+func (rbac *RBAC[T]) AddSSD(c SoDConstraint[T]) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.ssd[c.Name]; ok {
+		return ErrConstraintExist
+	}
+	if err := rbac.validSoD(c); err != nil {
+		return err
+	}
+	c = c.clone()
+	rbac.ssd[c.Name] = c
+	for id := range rbac.roles {
+		if err := rbac.checkSSD(id, []T{id}); err != nil {
+			delete(rbac.ssd, c.Name)
+			return err
+		}
+	}
+	for subject, roles := range rbac.subjects {
+		if err := rbac.checkSSD(subject, keys(roles)); err != nil {
+			delete(rbac.ssd, c.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveSSD unregisters the static separation of duty constraint `name`.
+func (rbac *RBAC[T]) RemoveSSD(name string) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.ssd[name]; !ok {
+		return ErrConstraintNotExist
+	}
+	delete(rbac.ssd, name)
+	return nil
+}
+
+// SSD returns copies of all static separation of duty constraints.
+func (rbac *RBAC[T]) SSD() []SoDConstraint[T] {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	result := make([]SoDConstraint[T], 0, len(rbac.ssd))
+	for _, c := range rbac.ssd {
+		result = append(result, c.clone())
+	}
+	return result
+}
+
+// AddDSD registers a dynamic separation of duty constraint.
+// It is enforced when roles are activated in a Session,
+// sessions already holding conflicting roles are not affected.
+// All roles of the constraint must exist and be distinct, and the
+// constraint is copied.
+func (rbac *RBAC[T]) AddDSD(c SoDConstraint[T]) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.dsd[c.Name]; ok {
+		return ErrConstraintExist
+	}
+	if err := rbac.validSoD(c); err != nil {
+		return err
+	}
+	rbac.dsd[c.Name] = c.clone()
+	return nil
+}
+
+// RemoveDSD unregisters the dynamic separation of duty constraint `name`.
+func (rbac *RBAC[T]) RemoveDSD(name string) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.dsd[name]; !ok {
+		return ErrConstraintNotExist
+	}
+	delete(rbac.dsd, name)
+	return nil
+}
+
+// DSD returns copies of all dynamic separation of duty constraints.
+func (rbac *RBAC[T]) DSD() []SoDConstraint[T] {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	result := make([]SoDConstraint[T], 0, len(rbac.dsd))
+	for _, c := range rbac.dsd {
+		result = append(result, c.clone())
+	}
+	return result
+}
+
+func (rbac *RBAC[T]) validSoD(c SoDConstraint[T]) error {
+	if c.Cardinality < 2 || len(c.Roles) < c.Cardinality {
+		return ErrInvalidConstraint
+	}
+	seen := make(map[T]struct{}, len(c.Roles))
+	for _, id := range c.Roles {
+		if _, ok := seen[id]; ok {
+			return fmt.Errorf("%w: %v is listed twice", ErrInvalidConstraint, id)
+		}
+		seen[id] = empty
+		if _, ok := rbac.roles[id]; !ok {
+			return &RoleNotFoundError[T]{id}
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of the constraint not sharing its roles.
+func (c SoDConstraint[T]) clone() SoDConstraint[T] {
+	c.Roles = append([]T(nil), c.Roles...)
+	return c
+}
+
+// checkSSD tests whether holding `roles`, and all roles they inherit,
+// breaks any static separation of duty constraint.
+func (rbac *RBAC[T]) checkSSD(id T, roles []T) error {
+	return checkSoD(rbac.ssd, ErrSSDViolation, id, rbac.ancestors(time.Time{}, roles...))
+}
+
+// checkInheritSSD tests the role `id`, roles inheriting from it and
+// subjects assigned to any of them, after the parents of `id` changed.
+func (rbac *RBAC[T]) checkInheritSSD(id T) error {
+	if len(rbac.ssd) == 0 {
+		return nil
+	}
+	affected := rbac.descendants(id)
+	for rid := range affected {
+		if err := rbac.checkSSD(rid, []T{rid}); err != nil {
+			return err
+		}
+	}
+	for subject, roles := range rbac.subjects {
+		for rid := range roles {
+			if _, ok := affected[rid]; ok {
+				if err := rbac.checkSSD(subject, keys(roles)); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func removeFromSoD[T comparable](constraints map[string]SoDConstraint[T], id T) {
+	for name, c := range constraints {
+		roles := make([]T, 0, len(c.Roles))
+		for _, rid := range c.Roles {
+			if rid != id {
+				roles = append(roles, rid)
+			}
+		}
+		c.Roles = roles
+		constraints[name] = c
+	}
+}
+
+func checkSoD[T comparable](constraints map[string]SoDConstraint[T],
+	sentinel error, id T, held map[T]struct{}) error {
+	for _, c := range constraints {
+		var conflict []T
+		for _, rid := range c.Roles {
+			if _, ok := held[rid]; ok {
+				conflict = append(conflict, rid)
+			}
+		}
+		if len(conflict) >= c.Cardinality {
+			return &SoDError[T]{
+				Err:        sentinel,
+				Constraint: c.Name,
+				ID:         id,
+				Roles:      conflict,
+			}
+		}
+	}
+	return nil
+}
+
+// ancestors returns `ids` and every role they inherit from at `now`.
+// If `now` is zero, inheritance edges are followed regardless of their
+// periods.
+func (rbac *RBAC[T]) ancestors(now time.Time, ids ...T) map[T]struct{} {
+	result := make(map[T]struct{})
+	stack := append([]T(nil), ids...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := result[id]; ok {
+			continue
+		}
+		result[id] = empty
+		for parent, period := range rbac.parents[id] {
+			if now.IsZero() || period.Contains(now) {
+				stack = append(stack, parent)
+			}
+		}
+	}
+	return result
+}
+
+// descendants returns `id` and every role inheriting from it.
+func (rbac *RBAC[T]) descendants(id T) map[T]struct{} {
+	result := map[T]struct{}{id: empty}
+	for changed := true; changed; {
+		changed = false
+		for rid, parents := range rbac.parents {
+			if _, ok := result[rid]; ok {
+				continue
+			}
+			for parent := range parents {
+				if _, ok := result[parent]; ok {
+					result[rid] = empty
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+func keys[T comparable, V any](m map[T]V) []T {
+	result := make([]T, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package gorbac
+
+import (
+	"errors"
+	"testing"
+)
+
+func prepareSoD(t *testing.T) *RBAC[string] {
+	r := New[string]()
+	for _, id := range []string{"requester", "approver", "auditor", "clerk", "manager"} {
+		assert(t, r.Add(NewRole(id)))
+	}
+	return r
+}
+
+func TestSSDAssignRole(t *testing.T) {
+	r := prepareSoD(t)
+	assert(t, r.AddSSD(SoDConstraint[string]{
+		Name:        "payment",
+		Roles:       []string{"requester", "approver"},
+		Cardinality: 2,
+	}))
+	assert(t, r.AssignRole("alice", "requester"))
+	assert(t, r.AssignRole("alice", "auditor"))
+	err := r.AssignRole("alice", "approver")
+	if !errors.Is(err, ErrSSDViolation) {
+		t.Fatalf("%s needed, but %v got", ErrSSDViolation, err)
+	}
+	var sodErr *SoDError[string]
+	if !errors.As(err, &sodErr) {
+		t.Fatalf("SoDError needed, but %T got", err)
+	}
+	if sodErr.Constraint != "payment" || sodErr.ID != "alice" || len(sodErr.Roles) != 2 {
+		t.Fatalf("Unexpected violation: %v", sodErr)
+	}
+	if len(r.GetRoles("alice")) != 2 {
+		t.Fatal("[alice] should have two roles")
+	}
+	assert(t, r.RevokeRole("alice", "requester"))
+	assert(t, r.AssignRole("alice", "approver"))
+}
+
+func TestSSDInheritance(t *testing.T) {
+	r := prepareSoD(t)
+	assert(t, r.AddSSD(SoDConstraint[string]{
+		Name:        "payment",
+		Roles:       []string{"requester", "approver"},
+		Cardinality: 2,
+	}))
+	assert(t, r.SetParent("clerk", "requester"))
+	if err := r.SetParent("clerk", "approver"); !errors.Is(err, ErrSSDViolation) {
+		t.Fatalf("%s needed, but %v got", ErrSSDViolation, err)
+	}
+	if _, ok := r.parents["clerk"]["approver"]; ok {
+		t.Fatal("A violating parent should not be bound")
+	}
+	assert(t, r.SetParent("manager", "approver"))
+	assert(t, r.AssignRole("bob", "manager"))
+	if err := r.AssignRole("bob", "clerk"); !errors.Is(err, ErrSSDViolation) {
+		t.Fatalf("%s needed, but %v got", ErrSSDViolation, err)
+	}
+	assert(t, r.AssignRole("carol", "clerk"))
+	// bob holds manager, which would inherit requester through clerk
+	if err := r.SetParents("manager", []string{"auditor", "clerk"}); !errors.Is(err, ErrSSDViolation) {
+		t.Fatalf("%s needed, but %v got", ErrSSDViolation, err)
+	}
+	if parents, _ := r.GetParents("manager"); len(parents) != 1 {
+		t.Fatal("[manager] should keep only one parent")
+	}
+}
+
+func TestSSDRegister(t *testing.T) {
+	r := prepareSoD(t)
+	c := SoDConstraint[string]{
+		Name:        "payment",
+		Roles:       []string{"requester", "approver", "auditor"},
+		Cardinality: 3,
+	}
+	if err := r.AddSSD(SoDConstraint[string]{Name: "bad", Roles: c.Roles, Cardinality: 1}); err != ErrInvalidConstraint {
+		t.Fatalf("%s needed", ErrInvalidConstraint)
+	}
+	if err := r.AddSSD(SoDConstraint[string]{Name: "bad", Roles: []string{"a", "b"}, Cardinality: 2}); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s needed", ErrRoleNotExist)
+	}
+	for _, add := range []func(SoDConstraint[string]) error{r.AddSSD, r.AddDSD} {
+		twice := SoDConstraint[string]{Name: "twice", Roles: []string{"requester", "requester"}, Cardinality: 2}
+		if err := add(twice); !errors.Is(err, ErrInvalidConstraint) {
+			t.Fatalf("%s needed, but %v got", ErrInvalidConstraint, err)
+		}
+	}
+	assert(t, r.AssignRole("alice", "requester"))
+	assert(t, r.AssignRole("alice", "approver"))
+	assert(t, r.AddSSD(c))
+	// registered constraints are copied in and out
+	c.Roles[0] = "approver"
+	r.SSD()[0].Roles[1] = "requester"
+	if got := r.SSD()[0].Roles; got[0] != "requester" || got[1] != "approver" {
+		t.Fatalf("The registered constraint shouldn't change, but %v got", got)
+	}
+	c.Roles[0] = "requester"
+	if err := r.AddSSD(c); err != ErrConstraintExist {
+		t.Fatalf("%s needed", ErrConstraintExist)
+	}
+	if err := r.AddSSD(SoDConstraint[string]{Name: "strict", Roles: c.Roles, Cardinality: 2}); !errors.Is(err, ErrSSDViolation) {
+		t.Fatalf("%s needed, but %v got", ErrSSDViolation, err)
+	}
+	if len(r.SSD()) != 1 {
+		t.Fatal("Only one constraint should be registered")
+	}
+	assert(t, r.Remove("auditor"))
+	if got := r.SSD()[0].Roles; len(got) != 2 {
+		t.Fatalf("Removed roles should leave the constraint, but %v got", got)
+	}
+	assert(t, r.RemoveSSD("payment"))
+	if err := r.RemoveSSD("payment"); err != ErrConstraintNotExist {
+		t.Fatalf("%s needed", ErrConstraintNotExist)
+	}
+}
@@ -0,0 +1,512 @@
+package gorbac
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tables of LoadCSV and ExportCSV
+const (
+	// CSVRoles has the columns role, name, description, tags and
+	// attributes, where tags are separated by ";" and attributes are
+	// `key=value` separated by ";". A `\`, ";" or "=" in a tag, a key
+	// or a value is escaped by `\`
+	CSVRoles = "roles"
+	// CSVGrants has the columns role, kind, permission, sep, not_before
+	// and not_after
+	CSVGrants = "grants"
+	// CSVEdges has the columns role, parent, not_before and not_after
+	CSVEdges = "edges"
+)
+
+var (
+	// ErrInvalidCSV occurred if a row of a CSV table can't be parsed
+	ErrInvalidCSV = errors.New("Invalid CSV row")
+)
+
+// csvColumns are the columns of the tables, the required ones first.
+var csvColumns = map[string]struct {
+	columns  []string
+	required int
+}{
+	CSVRoles:  {[]string{"role", "name", "description", "tags", "attributes"}, 1},
+	CSVGrants: {[]string{"role", "permission", "kind", "sep", "not_before", "not_after"}, 2},
+	CSVEdges:  {[]string{"role", "parent", "not_before", "not_after"}, 2},
+}
+
+// CSVError is a row of a CSV table which can't be loaded.
+type CSVError struct {
+	Table string
+	// Line is the line number in the table, 0 if the error is about the
+	// whole load
+	Line int
+	Err  error
+}
+
+func (e *CSVError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Table, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.Table, e.Line, e.Err)
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// CSVErrors are all the rows LoadCSV can't load.
+type CSVErrors []*CSVError
+
+func (e CSVErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e CSVErrors) Unwrap() []error {
+	result := make([]error, len(e))
+	for i, err := range e {
+		result[i] = err
+	}
+	return result
+}
+
+type csvRole[T comparable] struct {
+	line     int
+	id       T
+	metadata Metadata
+}
+
+type csvGrant[T comparable] struct {
+	line   int
+	id     T
+	p      Permission[T]
+	period Period
+}
+
+type csvEdge[T comparable] struct {
+	line       int
+	id, parent T
+	period     Period
+}
+
+// LoadCSV loads the tables of roles, grants and edges into the RBAC in
+// one transaction, any table may be nil. Each table starts with a header
+// naming its columns, in any order. IDs are decoded by the codec. A role
+// row adds the role or replaces its metadata, a grant row assigns the
+// permission, decoded like DecodePermission, and an edge row binds the
+// parent, both within the optional RFC 3339 times. Roles have to be in
+// the roles table or the RBAC already.
+//
+// Every invalid row, whether it can't be parsed or can't be applied, is
+// reported at once by CSVErrors in the order of the tables, and the RBAC
+// is left as it was. Circle inheritance is refused as well.
+func LoadCSV[T comparable](rbac *RBAC[T], codec IDCodec[T], roles, grants, edges io.Reader) error {
+	var errs CSVErrors
+	var rs []csvRole[T]
+	var gs []csvGrant[T]
+	var es []csvEdge[T]
+	errs = append(errs, readCSV(CSVRoles, roles, func(line int, row map[string]string) error {
+		id, err := codec.Decode(row["role"])
+		if err != nil {
+			return err
+		}
+		r := csvRole[T]{line: line, id: id}
+		r.metadata.Name = row["name"]
+		r.metadata.Description = row["description"]
+		for _, tag := range csvSplit(row["tags"], ';') {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				r.metadata.Tags = append(r.metadata.Tags, csvUnescape(tag))
+			}
+		}
+		for _, attr := range csvSplit(row["attributes"], ';') {
+			if strings.TrimSpace(attr) == "" {
+				continue
+			}
+			kv := csvSplit(attr, '=')
+			if len(kv) < 2 {
+				// still declared, so its grants and edges are checked
+				rs = append(rs, r)
+				return fmt.Errorf("%w: attribute %q should be key=value", ErrInvalidCSV, attr)
+			}
+			if r.metadata.Attributes == nil {
+				r.metadata.Attributes = make(map[string]string)
+			}
+			key, value := kv[0], attr[len(kv[0])+1:]
+			r.metadata.Attributes[csvUnescape(strings.TrimSpace(key))] = csvUnescape(strings.TrimSpace(value))
+		}
+		rs = append(rs, r)
+		return nil
+	})...)
+	errs = append(errs, readCSV(CSVGrants, grants, func(line int, row map[string]string) error {
+		sep := row["sep"]
+		if sep == "" {
+			sep = "/"
+		}
+		id, err := codec.Decode(row["role"])
+		if err != nil {
+			return err
+		}
+		p, err := DecodePermission(codec, row["kind"], row["permission"], sep)
+		if err != nil {
+			return err
+		}
+		period, err := csvPeriod(row)
+		if err != nil {
+			return err
+		}
+		gs = append(gs, csvGrant[T]{line, id, p, period})
+		return nil
+	})...)
+	errs = append(errs, readCSV(CSVEdges, edges, func(line int, row map[string]string) error {
+		id, err := codec.Decode(row["role"])
+		if err != nil {
+			return err
+		}
+		parent, err := codec.Decode(row["parent"])
+		if err != nil {
+			return err
+		}
+		period, err := csvPeriod(row)
+		if err != nil {
+			return err
+		}
+		es = append(es, csvEdge[T]{line, id, parent, period})
+		return nil
+	})...)
+
+	// the rows parsed are checked against the RBAC too, so every error
+	// is reported at once
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	// undo reverts every change if any row fails
+	var undo []func()
+	fail := func(table string, line int, err error) {
+		errs = append(errs, &CSVError{table, line, err})
+	}
+	declared := make(map[T]int)
+	for _, r := range rs {
+		if line, ok := declared[r.id]; ok {
+			fail(CSVRoles, r.line, fmt.Errorf("%w: role %v is on line %d already", ErrInvalidCSV, r.id, line))
+			continue
+		}
+		declared[r.id] = r.line
+		role, ok := rbac.roles[r.id]
+		if !ok {
+			role = NewRole(r.id)
+			rbac.add(role)
+			undo = append(undo, func() { delete(rbac.roles, r.id) })
+		}
+		mr, ok := role.(MetadataRole)
+		if !ok {
+			if !r.metadata.IsZero() {
+				fail(CSVRoles, r.line, fmt.Errorf("%w: %v", ErrMetadataNotSupported, r.id))
+			}
+			continue
+		}
+		old := mr.GetMetadata()
+		mr.SetMetadata(r.metadata)
+		undo = append(undo, func() { mr.SetMetadata(old) })
+	}
+	for _, g := range gs {
+		role, ok := rbac.roles[g.id]
+		if !ok {
+			fail(CSVGrants, g.line, &RoleNotFoundError[T]{g.id})
+			continue
+		}
+		pid := g.p.ID()
+		old := permissionOf(role, pid)
+		period := grantPeriods(role)[pid]
+		var err error
+		if g.period.IsZero() {
+			err = role.Assign(g.p)
+		} else if tr, ok := role.(TimedRole[T]); ok {
+			err = tr.AssignWithin(g.p, g.period)
+		} else {
+			err = fmt.Errorf("%w: %v", ErrTimedGrantNotSupported, g.id)
+		}
+		if err != nil {
+			fail(CSVGrants, g.line, err)
+			continue
+		}
+		undo = append(undo, func() {
+			role.Revoke(permissionOf(role, pid))
+			if old == nil {
+				return
+			}
+			if tr, ok := role.(TimedRole[T]); ok {
+				tr.AssignWithin(old, period)
+			} else {
+				role.Assign(old)
+			}
+		})
+	}
+	for _, e := range es {
+		missing := false
+		for _, id := range []T{e.id, e.parent} {
+			if _, ok := rbac.roles[id]; !ok {
+				fail(CSVEdges, e.line, &RoleNotFoundError[T]{id})
+				missing = true
+				break
+			}
+		}
+		if missing {
+			continue
+		}
+		period, bound := rbac.parents[e.id][e.parent]
+		if err := rbac.bindParents(e.id, []T{e.parent}, e.period); err != nil {
+			fail(CSVEdges, e.line, err)
+			continue
+		}
+		undo = append(undo, func() {
+			if bound {
+				rbac.parents[e.id][e.parent] = period
+			} else if delete(rbac.parents[e.id], e.parent); len(rbac.parents[e.id]) == 0 {
+				delete(rbac.parents, e.id)
+			}
+		})
+	}
+	if err := inherCircle(rbac); err != nil {
+		fail(CSVEdges, 0, err)
+	}
+	if len(errs) > 0 {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		errs.sort()
+		return errs
+	}
+	return nil
+}
+
+// sort the errors by table and line, the errors about a whole table last.
+func (e CSVErrors) sort() {
+	order := map[string]int{CSVRoles: 0, CSVGrants: 1, CSVEdges: 2}
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].Table != e[j].Table {
+			return order[e[i].Table] < order[e[j].Table]
+		}
+		li, lj := e[i].Line, e[j].Line
+		if li == 0 || lj == 0 {
+			return lj == 0 && li != 0
+		}
+		return li < lj
+	})
+}
+
+// permissionOf returns the permission `id` assigned to the role, or nil.
+func permissionOf[T comparable](role Role[T], id T) Permission[T] {
+	for _, p := range role.Permissions() {
+		if p.ID() == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// readCSV reads the table `r` if it isn't nil, calling `f` with each
+// row by the column names. Empty cells of required columns and the
+// errors returned by `f` are collected.
+func readCSV(table string, r io.Reader, f func(line int, row map[string]string) error) (errs CSVErrors) {
+	if r == nil {
+		return nil
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			err = fmt.Errorf("%w: header expected", ErrInvalidCSV)
+		}
+		return CSVErrors{{table, 1, err}}
+	}
+	spec := csvColumns[table]
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if indexOf(spec.columns, name) < 0 {
+			errs = append(errs, &CSVError{table, 1, fmt.Errorf("%w: unknown column %q", ErrInvalidCSV, name)})
+		}
+		index[name] = i
+	}
+	for _, name := range spec.columns[:spec.required] {
+		if _, ok := index[name]; !ok {
+			errs = append(errs, &CSVError{table, 1, fmt.Errorf("%w: column %q expected", ErrInvalidCSV, name)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return errs
+		}
+		if err != nil {
+			// the rest of the table can't be trusted
+			return append(errs, &CSVError{table, 0, err})
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(header) {
+			errs = append(errs, &CSVError{table, line, fmt.Errorf("%w: %d columns expected, but %d got", ErrInvalidCSV, len(header), len(record))})
+			continue
+		}
+		row := make(map[string]string, len(header))
+		for name, i := range index {
+			row[name] = strings.TrimSpace(record[i])
+		}
+		missing := false
+		for _, name := range spec.columns[:spec.required] {
+			if row[name] == "" {
+				errs = append(errs, &CSVError{table, line, fmt.Errorf("%w: %s is empty", ErrInvalidCSV, name)})
+				missing = true
+			}
+		}
+		if missing {
+			continue
+		}
+		if err := f(line, row); err != nil {
+			errs = append(errs, &CSVError{table, line, err})
+		}
+	}
+}
+
+// csvPeriod parses the columns not_before and not_after.
+func csvPeriod(row map[string]string) (period Period, err error) {
+	if s := row["not_before"]; s != "" {
+		if period.NotBefore, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return period, err
+		}
+	}
+	if s := row["not_after"]; s != "" {
+		if period.NotAfter, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return period, err
+		}
+	}
+	return period, nil
+}
+
+// csvTime formats a bound of a period, empty if it's unbounded.
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// ExportCSV writes a snapshot of the roles, grants and edges as the
+// tables read by LoadCSV, IDs encoded by the codec and sorted for
+// review. Any writer may be nil to skip its table. Subjects, constraints
+// and limits aren't exported.
+func ExportCSV[T comparable](rbac *RBAC[T], codec IDCodec[T], roles, grants, edges io.Writer) error {
+	rbac.mutex.RLock()
+	rs, gs, es, err := csvRows(rbac, codec)
+	rbac.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	for _, t := range []struct {
+		w      io.Writer
+		header []string
+		rows   [][]string
+	}{
+		{roles, []string{"role", "name", "description", "tags", "attributes"}, rs},
+		{grants, []string{"role", "kind", "permission", "sep", "not_before", "not_after"}, gs},
+		{edges, []string{"role", "parent", "not_before", "not_after"}, es},
+	} {
+		if t.w == nil {
+			continue
+		}
+		sort.Slice(t.rows, func(i, j int) bool {
+			return strings.Join(t.rows[i], "\x00") < strings.Join(t.rows[j], "\x00")
+		})
+		cw := csv.NewWriter(t.w)
+		cw.Write(t.header)
+		cw.WriteAll(t.rows)
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvRows returns the rows of the tables of roles, grants and edges.
+func csvRows[T comparable](rbac *RBAC[T], codec IDCodec[T]) (rs, gs, es [][]string, err error) {
+	for rid, role := range rbac.roles {
+		id, err := codec.Encode(rid)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		m := metadataOf(role)
+		tags := make([]string, len(m.Tags))
+		for i, tag := range m.Tags {
+			tags[i] = csvEscaper.Replace(tag)
+		}
+		attributes := make([]string, 0, len(m.Attributes))
+		for key, value := range m.Attributes {
+			attributes = append(attributes, csvEscaper.Replace(key)+"="+csvEscaper.Replace(value))
+		}
+		sort.Strings(attributes)
+		rs = append(rs, []string{id, m.Name, m.Description, strings.Join(tags, ";"), strings.Join(attributes, ";")})
+		periods := grantPeriods(role)
+		for _, p := range role.Permissions() {
+			kind, pid, sep, err := EncodePermission(codec, p)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("%s: %w", id, err)
+			}
+			period := periods[p.ID()]
+			gs = append(gs, []string{id, kind, pid, sep, csvTime(period.NotBefore), csvTime(period.NotAfter)})
+		}
+		for rparent, period := range rbac.parents[rid] {
+			parent, err := codec.Encode(rparent)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			es = append(es, []string{id, parent, csvTime(period.NotBefore), csvTime(period.NotAfter)})
+		}
+	}
+	return rs, gs, es, nil
+}
+
+// csvEscaper escapes the separators of tags and attributes.
+var csvEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, "=", `\=`)
+
+// csvSplit splits `s` by the `sep` not escaped by "\", keeping the
+// escapes.
+func csvSplit(s string, sep byte) []string {
+	var result []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			result = append(result, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(result, s[start:])
+}
+
+// csvUnescape removes the "\" escaping the next character.
+func csvUnescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
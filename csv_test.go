@@ -0,0 +1,169 @@
+package gorbac
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	csvRolesTable = `role,name,tags,attributes
+reader,Reader,,
+editor,Editor,staff;content,team=news
+auditor,,,
+`
+	csvGrantsTable = `role,kind,permission,sep,not_before,not_after
+reader,,read,,,
+editor,layer,articles/edit,,,2030-01-01T00:00:00Z
+editor,mask,comment:read|update,,,
+auditor,resource,document:*:view,,,
+`
+	csvEdgesTable = `role,parent
+editor,reader
+auditor,reader
+`
+)
+
+func TestCSV(t *testing.T) {
+	r := New[string]()
+	assert(t, LoadCSV(r, StringCodec{}, strings.NewReader(csvRolesTable), strings.NewReader(csvGrantsTable), strings.NewReader(csvEdgesTable)))
+	if !r.IsGranted("editor", NewPermission("read"), nil) {
+		t.Fatal("[read] should be granted by reader")
+	}
+	if !r.IsGranted("editor", NewLayerPermission("articles/edit/1", "/"), nil) {
+		t.Fatal("[articles/edit/1] should be granted")
+	}
+	m, err := r.GetMetadata("editor")
+	assert(t, err)
+	expected := Metadata{Name: "Editor", Tags: []string{"staff", "content"}, Attributes: map[string]string{"team": "news"}}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("%v expected, but %v got", expected, m)
+	}
+	r.SetClock(func() time.Time { return time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC) })
+	if r.IsGranted("editor", NewLayerPermission("articles/edit/1", "/"), nil) {
+		t.Fatal("[articles/edit] should be expired")
+	}
+
+	var roles, grants, edges strings.Builder
+	assert(t, ExportCSV(r, StringCodec{}, &roles, &grants, &edges))
+	loaded := New[string]()
+	assert(t, LoadCSV(loaded, StringCodec{}, strings.NewReader(roles.String()), strings.NewReader(grants.String()), strings.NewReader(edges.String())))
+	if !reflect.DeepEqual(ExportPolicy(r), ExportPolicy(loaded)) {
+		t.Fatalf("The snapshot expected to be loaded:\n%s%s%s", roles.String(), grants.String(), edges.String())
+	}
+	expectedEdges := "role,parent,not_before,not_after\nauditor,reader,,\neditor,reader,,\n"
+	if edges.String() != expectedEdges {
+		t.Fatalf("%q expected, but %q got", expectedEdges, edges.String())
+	}
+}
+
+func TestCSVEscapes(t *testing.T) {
+	r := New[string]()
+	role := NewRole("editor")
+	role.Metadata = Metadata{
+		Tags:       []string{"a;b", `c\d`, "e=f"},
+		Attributes: map[string]string{"k=1;": "v=2;3", `x\`: "y"},
+	}
+	assert(t, r.Add(role))
+	var roles strings.Builder
+	assert(t, ExportCSV(r, StringCodec{}, &roles, nil, nil))
+	expected := `role,name,description,tags,attributes
+editor,,,a\;b;c\\d;e\=f,k\=1\;=v\=2\;3;x\\=y
+`
+	if roles.String() != expected {
+		t.Fatalf("%q expected, but %q got", expected, roles.String())
+	}
+	loaded := New[string]()
+	assert(t, LoadCSV(loaded, StringCodec{}, strings.NewReader(roles.String()), nil, nil))
+	m, err := loaded.GetMetadata("editor")
+	assert(t, err)
+	if !reflect.DeepEqual(m, role.Metadata) {
+		t.Fatalf("%v expected, but %v got", role.Metadata, m)
+	}
+}
+
+func TestLoadCSVErrors(t *testing.T) {
+	r := New[string]()
+	assert(t, LoadCSV(r, StringCodec{}, strings.NewReader(csvRolesTable), nil, nil))
+	grants := `role,permission,kind
+editor,write,
+nobody,read,
+editor,,
+editor,comment:fly,mask
+`
+	edges := `role,parent
+editor,auditor
+editor,nobody
+`
+	err := LoadCSV(r, StringCodec{}, nil, strings.NewReader(grants), strings.NewReader(edges))
+	var errs CSVErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("CSVErrors expected, but %v got", err)
+	}
+	// the rows which can't be parsed and the missing roles are reported
+	// at once
+	expected := []struct {
+		table string
+		line  int
+	}{{CSVGrants, 3}, {CSVGrants, 4}, {CSVGrants, 5}, {CSVEdges, 3}}
+	if len(errs) != len(expected) || !errors.Is(err, ErrUnknownAction) || !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%v expected, but %v got", expected, err)
+	}
+	for i, e := range expected {
+		if errs[i].Table != e.table || errs[i].Line != e.line {
+			t.Fatalf("%s:%d expected, but %v got", e.table, e.line, errs[i])
+		}
+	}
+	if r.IsGranted("editor", NewPermission("write"), nil) {
+		t.Fatal("[write] shouldn't be loaded by a failed transaction")
+	}
+
+	grants = "role,permission\neditor,write\nnobody,read\n"
+	err = LoadCSV(r, StringCodec{}, nil, strings.NewReader(grants), strings.NewReader(edges))
+	if !errors.As(err, &errs) || len(errs) != 2 || !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("Two missing roles expected, but %v got", err)
+	}
+	if errs[0].Table != CSVGrants || errs[0].Line != 3 || errs[1].Table != CSVEdges || errs[1].Line != 3 {
+		t.Fatalf("grants:3 and edges:3 expected, but %v got", err)
+	}
+	if r.IsGranted("editor", NewPermission("write"), nil) {
+		t.Fatal("[write] shouldn't be loaded by a failed transaction")
+	}
+	if parents, _ := r.GetParents("editor"); len(parents) != 0 {
+		t.Fatalf("No parents expected, but %v got", parents)
+	}
+
+	edges = "role,parent\neditor,auditor\nauditor,editor\n"
+	if err := LoadCSV(r, StringCodec{}, nil, nil, strings.NewReader(edges)); !errors.Is(err, ErrFoundCircle) {
+		t.Fatalf("%s expected, but %v got", ErrFoundCircle, err)
+	}
+	if parents, _ := r.GetParents("editor"); len(parents) != 0 {
+		t.Fatalf("No parents expected, but %v got", parents)
+	}
+	if err := LoadCSV(r, StringCodec{}, strings.NewReader("id,name\n"), nil, nil); !errors.Is(err, ErrInvalidCSV) {
+		t.Fatalf("%s expected, but %v got", ErrInvalidCSV, err)
+	}
+}
+
+func TestCSVCodec(t *testing.T) {
+	r := New[int]()
+	roles := "role,name\n1,Reader\n2,Editor\n"
+	grants := "role,permission\n1,10\n"
+	edges := "role,parent\n2,1\n"
+	assert(t, LoadCSV(r, IntCodec[int]{}, strings.NewReader(roles), strings.NewReader(grants), strings.NewReader(edges)))
+	if !r.IsGranted(2, NewPermission(10), nil) {
+		t.Fatal("[10] should be granted by 1")
+	}
+	var b strings.Builder
+	assert(t, ExportCSV(r, IntCodec[int]{}, nil, &b, nil))
+	expected := "role,kind,permission,sep,not_before,not_after\n1,std,10,,,\n"
+	if b.String() != expected {
+		t.Fatalf("%q expected, but %q got", expected, b.String())
+	}
+	grants = "role,permission,kind\n1,a/b,layer\nx,10,\n"
+	if err := LoadCSV(r, IntCodec[int]{}, nil, strings.NewReader(grants), nil); !errors.Is(err, ErrUnsupportedPermission) || !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("%s and %s expected, but %v got", ErrUnsupportedPermission, ErrInvalidID, err)
+	}
+}
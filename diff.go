@@ -0,0 +1,263 @@
+package gorbac
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Edge is an inheritance edge from a role to its parent.
+type Edge[T comparable] struct {
+	Role   T `json:"role"`
+	Parent T `json:"parent"`
+}
+
+// Grant is a permission of a role.
+type Grant[T comparable] struct {
+	Role       T `json:"role"`
+	Permission T `json:"permission"`
+}
+
+// PolicyDiff is the difference between two policies.
+type PolicyDiff[T comparable] struct {
+	AddedRoles    []T        `json:"added_roles,omitempty"`
+	RemovedRoles  []T        `json:"removed_roles,omitempty"`
+	AddedEdges    []Edge[T]  `json:"added_edges,omitempty"`
+	RemovedEdges  []Edge[T]  `json:"removed_edges,omitempty"`
+	AddedGrants   []Grant[T] `json:"added_grants,omitempty"`
+	RemovedGrants []Grant[T] `json:"removed_grants,omitempty"`
+	// RetimedEdges are the edges in both policies within different
+	// periods
+	RetimedEdges []Edge[T] `json:"retimed_edges,omitempty"`
+	// RetimedGrants are the same permissions granted in both policies
+	// within different periods
+	RetimedGrants []Grant[T] `json:"retimed_grants,omitempty"`
+	// Granted are the pairs denied by the old policy but granted by the
+	// new one, once inheritance is considered
+	Granted []Grant[T] `json:"granted,omitempty"`
+	// Denied are the pairs granted by the old policy but denied by the
+	// new one, once inheritance is considered
+	Denied []Grant[T] `json:"denied,omitempty"`
+}
+
+// IsZero returns true if both policies are the same.
+func (d *PolicyDiff[T]) IsZero() bool {
+	return len(d.AddedRoles) == 0 && len(d.RemovedRoles) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 &&
+		len(d.AddedGrants) == 0 && len(d.RemovedGrants) == 0 &&
+		len(d.RetimedEdges) == 0 && len(d.RetimedGrants) == 0 &&
+		len(d.Granted) == 0 && len(d.Denied) == 0
+}
+
+// String renders the difference as text, one change a line, the IDs by
+// fmt.Sprint. Structural changes are prefixed by "+" or "-", changed
+// periods by "~", and their effects by "granted" or "denied".
+func (d *PolicyDiff[T]) String() string {
+	var b strings.Builder
+	d.Format(&b, nil)
+	return b.String()
+}
+
+// Format writes the difference as text like String, the IDs encoded by
+// the codec, or by fmt.Sprint if it's nil.
+func (d *PolicyDiff[T]) Format(w io.Writer, codec IDCodec[T]) error {
+	var err error
+	enc := func(id T) string {
+		s, e := encodeID(codec, id)
+		if err == nil {
+			err = e
+		}
+		return s
+	}
+	var b strings.Builder
+	for _, id := range d.AddedRoles {
+		fmt.Fprintf(&b, "+ role %s\n", enc(id))
+	}
+	for _, id := range d.RemovedRoles {
+		fmt.Fprintf(&b, "- role %s\n", enc(id))
+	}
+	for _, e := range d.AddedEdges {
+		fmt.Fprintf(&b, "+ parent %s -> %s\n", enc(e.Role), enc(e.Parent))
+	}
+	for _, e := range d.RemovedEdges {
+		fmt.Fprintf(&b, "- parent %s -> %s\n", enc(e.Role), enc(e.Parent))
+	}
+	for _, g := range d.AddedGrants {
+		fmt.Fprintf(&b, "+ grant %s %s\n", enc(g.Role), enc(g.Permission))
+	}
+	for _, g := range d.RemovedGrants {
+		fmt.Fprintf(&b, "- grant %s %s\n", enc(g.Role), enc(g.Permission))
+	}
+	for _, e := range d.RetimedEdges {
+		fmt.Fprintf(&b, "~ parent %s -> %s\n", enc(e.Role), enc(e.Parent))
+	}
+	for _, g := range d.RetimedGrants {
+		fmt.Fprintf(&b, "~ grant %s %s\n", enc(g.Role), enc(g.Permission))
+	}
+	for _, g := range d.Granted {
+		fmt.Fprintf(&b, "granted %s %s\n", enc(g.Role), enc(g.Permission))
+	}
+	for _, g := range d.Denied {
+		fmt.Fprintf(&b, "denied %s %s\n", enc(g.Role), enc(g.Permission))
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// Diff compares the policy `old` with `new`: the added and removed roles,
+// inheritance edges and direct grants, the edges and grants whose
+// periods changed, and the role and permission pairs flipping between
+// granted and denied at the moment. A circle inheritance is compared as
+// well, each role on it visited once.
+// The pairs checked are every role of either policy with every
+// permission assigned in either policy. A permission replaced by one of
+// the same ID but a different value, e.g. a narrowed MaskPermission, is
+// reported as removed and added, and its pairs as both granted and
+// denied if it's matched differently in either direction.
+func Diff[T comparable](old, new *RBAC[T]) *PolicyDiff[T] {
+	a, b := snapshot(old), snapshot(new)
+	d := &PolicyDiff[T]{}
+	for id := range b.grants {
+		if _, ok := a.grants[id]; !ok {
+			d.AddedRoles = append(d.AddedRoles, id)
+		}
+	}
+	for id := range a.grants {
+		if _, ok := b.grants[id]; !ok {
+			d.RemovedRoles = append(d.RemovedRoles, id)
+		}
+	}
+	d.AddedEdges, d.RemovedEdges = diffEdges(a.parents, b.parents), diffEdges(b.parents, a.parents)
+	d.AddedGrants, d.RemovedGrants = diffGrants(a.grants, b.grants), diffGrants(b.grants, a.grants)
+	for id, parents := range b.parents {
+		for parent, period := range parents {
+			if q, ok := a.parents[id][parent]; ok && !q.Equal(period) {
+				d.RetimedEdges = append(d.RetimedEdges, Edge[T]{id, parent})
+			}
+		}
+	}
+	for id, ps := range b.grants {
+		for pid, p := range ps {
+			if q, ok := a.grants[id][pid]; ok && reflect.DeepEqual(p, q) &&
+				!a.periods[id][pid].Equal(b.periods[id][pid]) {
+				d.RetimedGrants = append(d.RetimedGrants, Grant[T]{id, pid})
+			}
+		}
+	}
+
+	// every permission by ID, in both policies
+	permissions := make(map[T][]Permission[T])
+	roles := make(map[T]struct{})
+	for _, s := range []policySnapshot[T]{a, b} {
+		for id, grants := range s.grants {
+			roles[id] = empty
+			for pid, p := range grants {
+				permissions[pid] = append(permissions[pid], p)
+			}
+		}
+	}
+	granted, denied := make(map[Grant[T]]struct{}), make(map[Grant[T]]struct{})
+	for id := range roles {
+		for pid, ps := range permissions {
+			for _, p := range ps {
+				before, after := old.IsGranted(id, p, nil), new.IsGranted(id, p, nil)
+				switch {
+				case !before && after:
+					granted[Grant[T]{id, pid}] = empty
+				case before && !after:
+					denied[Grant[T]{id, pid}] = empty
+				}
+			}
+		}
+	}
+	d.Granted, d.Denied = keys(granted), keys(denied)
+
+	sortIDs(d.AddedRoles)
+	sortIDs(d.RemovedRoles)
+	for _, edges := range [][]Edge[T]{d.AddedEdges, d.RemovedEdges, d.RetimedEdges} {
+		sort.Slice(edges, func(i, j int) bool {
+			return fmt.Sprint(edges[i].Role, " ", edges[i].Parent) <
+				fmt.Sprint(edges[j].Role, " ", edges[j].Parent)
+		})
+	}
+	for _, grants := range [][]Grant[T]{d.AddedGrants, d.RemovedGrants, d.RetimedGrants, d.Granted, d.Denied} {
+		sort.Slice(grants, func(i, j int) bool {
+			return fmt.Sprint(grants[i].Role, " ", grants[i].Permission) <
+				fmt.Sprint(grants[j].Role, " ", grants[j].Permission)
+		})
+	}
+	return d
+}
+
+// policySnapshot is a copy of the roles, edges and direct grants.
+type policySnapshot[T comparable] struct {
+	roles   Roles[T]
+	parents map[T]map[T]Period
+	grants  map[T]map[T]Permission[T]
+	// periods of time-bounded grants
+	periods map[T]map[T]Period
+}
+
+func snapshot[T comparable](rbac *RBAC[T]) policySnapshot[T] {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	s := policySnapshot[T]{
+		roles:   make(Roles[T], len(rbac.roles)),
+		parents: make(map[T]map[T]Period),
+		grants:  make(map[T]map[T]Permission[T], len(rbac.roles)),
+		periods: make(map[T]map[T]Period),
+	}
+	for id, role := range rbac.roles {
+		s.roles[id] = role
+		grants := make(map[T]Permission[T])
+		for _, p := range role.Permissions() {
+			grants[p.ID()] = p
+		}
+		s.grants[id] = grants
+		if periods := grantPeriods(role); len(periods) > 0 {
+			s.periods[id] = periods
+		}
+	}
+	for id, parents := range rbac.parents {
+		s.parents[id] = make(map[T]Period, len(parents))
+		for parent, period := range parents {
+			s.parents[id][parent] = period
+		}
+	}
+	return s
+}
+
+// diffEdges returns the edges in `b` but not in `a`.
+func diffEdges[T comparable](a, b map[T]map[T]Period) (edges []Edge[T]) {
+	for id, parents := range b {
+		for parent := range parents {
+			if _, ok := a[id][parent]; !ok {
+				edges = append(edges, Edge[T]{id, parent})
+			}
+		}
+	}
+	return
+}
+
+// diffGrants returns the grants in `b` but not in `a`.
+func diffGrants[T comparable](a, b map[T]map[T]Permission[T]) (grants []Grant[T]) {
+	for id, ps := range b {
+		for pid, p := range ps {
+			if q, ok := a[id][pid]; !ok || !reflect.DeepEqual(p, q) {
+				grants = append(grants, Grant[T]{id, pid})
+			}
+		}
+	}
+	return
+}
+
+func sortIDs[T comparable](ids []T) {
+	sort.Slice(ids, func(i, j int) bool {
+		return fmt.Sprint(ids[i]) < fmt.Sprint(ids[j])
+	})
+}
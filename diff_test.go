@@ -0,0 +1,119 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	build := func(parents map[string][]string, grants map[string][]Permission[string]) *RBAC[string] {
+		r := New[string]()
+		for id, ps := range grants {
+			role := NewRole(id)
+			for _, p := range ps {
+				assert(t, role.Assign(p))
+			}
+			assert(t, r.Add(role))
+		}
+		for id, ps := range parents {
+			assert(t, r.SetParents(id, ps))
+		}
+		return r
+	}
+	old := build(map[string][]string{
+		"admin":  {"editor"},
+		"editor": {"reader"},
+	}, map[string][]Permission[string]{
+		"admin":  {NewPermission("delete")},
+		"editor": {NewPermission("write"), NewMaskPermission("comment", ActionRead|ActionUpdate)},
+		"reader": {NewPermission("read")},
+		"guest":  {NewPermission("read")},
+	})
+	new := build(map[string][]string{
+		"admin":   {"editor"},
+		"auditor": {"reader"},
+	}, map[string][]Permission[string]{
+		"admin":   {NewPermission("delete")},
+		"editor":  {NewPermission("write"), NewPermission("read"), NewMaskPermission("comment", ActionRead)},
+		"reader":  {NewPermission("read")},
+		"auditor": {},
+	})
+
+	d := Diff(old, new)
+	text := `+ role auditor
+- role guest
++ parent auditor -> reader
+- parent editor -> reader
++ grant editor comment
++ grant editor read
+- grant editor comment
+- grant guest read
+granted auditor read
+denied admin comment
+denied editor comment
+denied guest read
+`
+	if d.String() != text {
+		t.Fatalf("%q expected, but %q got", text, d.String())
+	}
+	// the edge replaced by a direct grant keeps editor and admin reading
+	for _, g := range d.Denied {
+		if g.Permission == "read" && g.Role != "guest" {
+			t.Fatalf("[%s] should keep reading", g.Role)
+		}
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PolicyDiff[string]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != text {
+		t.Fatalf("%q expected, but %q got", text, decoded.String())
+	}
+
+	if d := Diff(old, old); !d.IsZero() {
+		t.Fatalf("No difference expected, but %q got", d)
+	}
+}
+
+func TestDiffPeriods(t *testing.T) {
+	build := func(until time.Time) *RBAC[string] {
+		r := New[string]()
+		assert(t, r.Add(NewRole("reader")))
+		assert(t, r.Add(NewRole("contractor")))
+		assert(t, r.AssignWithin("reader", NewPermission("read"), Period{NotAfter: until}))
+		assert(t, r.SetParentWithin("contractor", "reader", Period{NotAfter: until}))
+		return r
+	}
+	now := time.Now()
+	old, new := build(now.Add(time.Hour)), build(now.Add(2*time.Hour))
+	text := "~ parent contractor -> reader\n~ grant reader read\n"
+	if d := Diff(old, new); d.String() != text {
+		t.Fatalf("%q expected, but %q got", text, d.String())
+	}
+	if d := Diff(old, build(now.Add(time.Hour))); !d.IsZero() {
+		t.Fatalf("No difference expected, but %q got", d)
+	}
+}
+
+func TestDiffCircle(t *testing.T) {
+	build := func(p string) *RBAC[string] {
+		r := New[string]()
+		a, b := NewRole("a"), NewRole("b")
+		assert(t, b.Assign(NewPermission(p)))
+		assert(t, r.Add(a))
+		assert(t, r.Add(b))
+		assert(t, r.SetParent("a", "b"))
+		assert(t, r.SetParent("b", "a"))
+		return r
+	}
+	text := "+ grant b y\n- grant b x\ngranted a y\ngranted b y\ndenied a x\ndenied b x\n"
+	if d := Diff(build("x"), build("y")); d.String() != text {
+		t.Fatalf("%q expected, but %q got", text, d.String())
+	}
+}
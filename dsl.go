@@ -0,0 +1,806 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrDSLSyntax occurred if a policy text can't be parsed
+	ErrDSLSyntax = errors.New("Syntax error")
+	// ErrDSLUnsupported occurred if a policy text uses a feature goRBAC
+	// doesn't have
+	ErrDSLUnsupported = errors.New("Not supported")
+)
+
+// DSLError locates an error in a policy text.
+type DSLError struct {
+	Line, Column int
+	// Err is ErrDSLSyntax, ErrDSLUnsupported or an error of a value
+	Err error
+	Msg string
+}
+
+func (e *DSLError) Error() string {
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Err, e.Msg)
+}
+
+func (e *DSLError) Unwrap() error {
+	return e.Err
+}
+
+// ParseDSL parses a policy text:
+//
+//	# comments start with "#"
+//	role editor extends author, reviewer {
+//		name "Editor"
+//		description "Writes and reviews articles"
+//		tag staff, content
+//		attribute team news
+//		grant add-text
+//		grant layer articles/edit
+//		grant layer "shop::orders" sep ::
+//		grant resource document:*:edit
+//		grant mask comment read|update
+//		grant regex "^report-.*$"
+//		grant publish from 2024-01-01T00:00:00Z until 2025-01-01T00:00:00Z
+//		parent photographer until 2025-01-01T00:00:00Z
+//		limit holders 2 parents 3
+//	}
+//	subject alice {
+//		assign editor
+//	}
+//	ssd payment 2 payment-requester, payment-approver
+//	dsd teller 2 teller, auditor
+//
+// Statements end with a new line or ";". Words containing spaces or
+// any of `{};,"#` are quoted as Go strings. Times are RFC 3339.
+// IDs of roles, subjects and standard permissions are decoded by the
+// codec. Parents, assigned roles and roles of constraints must be
+// declared by a `role` block, each one which isn't is reported by a
+// DSLError wrapping a *RoleNotFoundError. A `deny` statement is refused
+// with ErrDSLUnsupported, since goRBAC has no negative permissions.
+func ParseDSL[T comparable](r io.Reader, codec IDCodec[T]) (*Policy[T], error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := lexDSL(string(data))
+	if err != nil {
+		return nil, err
+	}
+	p := &dslParser{
+		tokens: tokens,
+		policy: &Policy[string]{Roles: make(map[string]PolicyRole[string])},
+		decode: func(s string) error {
+			_, err := codec.Decode(s)
+			return err
+		},
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	if err := p.checkRoles(); err != nil {
+		return nil, err
+	}
+	return DecodePolicy(p.policy, codec)
+}
+
+type dslKind int
+
+const (
+	dslWord dslKind = iota
+	dslEnd          // a new line or ";"
+	dslComma
+	dslOpen
+	dslClose
+	dslEOF
+)
+
+type dslToken struct {
+	kind         dslKind
+	text         string
+	quoted       bool
+	line, column int
+}
+
+func (t dslToken) String() string {
+	switch t.kind {
+	case dslWord:
+		return strconv.Quote(t.text)
+	case dslEnd:
+		return "end of statement"
+	case dslEOF:
+		return "end of file"
+	}
+	return "`" + t.text + "`"
+}
+
+// dslSpecial are the characters ending an unquoted word.
+const dslSpecial = `{};,"#`
+
+func lexDSL(text string) ([]dslToken, error) {
+	var tokens []dslToken
+	line, column := 1, 1
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		t := dslToken{line: line, column: column}
+		switch {
+		case c == '\n':
+			t.kind, t.text = dslEnd, "\n"
+			tokens = append(tokens, t)
+			i++
+			line, column = line+1, 1
+			continue
+		case unicode.IsSpace(c):
+			i++
+			column++
+			continue
+		case c == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case c == ';':
+			t.kind, t.text = dslEnd, ";"
+		case c == ',':
+			t.kind, t.text = dslComma, ","
+		case c == '{':
+			t.kind, t.text = dslOpen, "{"
+		case c == '}':
+			t.kind, t.text = dslClose, "}"
+		case c == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"' && runes[j] != '\n'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) || runes[j] != '"' {
+				return nil, &DSLError{line, column, ErrDSLSyntax, "unterminated string"}
+			}
+			s, err := strconv.Unquote(string(runes[i : j+1]))
+			if err != nil {
+				return nil, &DSLError{line, column, ErrDSLSyntax, "invalid string " + string(runes[i:j+1])}
+			}
+			t.kind, t.text, t.quoted = dslWord, s, true
+			tokens = append(tokens, t)
+			column += j + 1 - i
+			i = j + 1
+			continue
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune(dslSpecial, runes[j]) {
+				j++
+			}
+			t.kind, t.text = dslWord, string(runes[i:j])
+			tokens = append(tokens, t)
+			column += j - i
+			i = j
+			continue
+		}
+		tokens = append(tokens, t)
+		i++
+		column++
+	}
+	return append(tokens, dslToken{kind: dslEOF, line: line, column: column}), nil
+}
+
+type dslParser struct {
+	tokens []dslToken
+	pos    int
+	policy *Policy[string]
+	// decode checks an ID is decoded by the codec
+	decode func(string) error
+	// roles are the tokens referring to roles, which must be declared
+	roles []dslToken
+}
+
+func (p *dslParser) peek() dslToken {
+	return p.tokens[p.pos]
+}
+
+func (p *dslParser) next() dslToken {
+	t := p.tokens[p.pos]
+	if t.kind != dslEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *dslParser) errorf(t dslToken, format string, args ...any) error {
+	return &DSLError{t.line, t.column, ErrDSLSyntax, fmt.Sprintf(format, args...)}
+}
+
+func (p *dslParser) skipEnds() {
+	for p.peek().kind == dslEnd {
+		p.next()
+	}
+}
+
+// statement returns the tokens up to the end of the statement, a block
+// or the end of a block, which is consumed only if it's a dslEnd.
+func (p *dslParser) statement() []dslToken {
+	var result []dslToken
+	for {
+		switch t := p.peek(); t.kind {
+		case dslEnd:
+			p.next()
+			return result
+		case dslOpen, dslClose, dslEOF:
+			return result
+		default:
+			result = append(result, p.next())
+		}
+	}
+}
+
+// keyword tests if `t` is the unquoted word `word`.
+func keyword(t dslToken, word string) bool {
+	return t.kind == dslWord && !t.quoted && t.text == word
+}
+
+// word returns the text of a word token.
+func (p *dslParser) word(tokens []dslToken, i int, what string, at dslToken) (string, error) {
+	if i >= len(tokens) {
+		return "", p.errorf(at, "%s expected", what)
+	}
+	if tokens[i].kind != dslWord {
+		return "", p.errorf(tokens[i], "%s expected, but %s found", what, tokens[i])
+	}
+	return tokens[i].text, nil
+}
+
+// list parses words separated by commas.
+func (p *dslParser) list(tokens []dslToken, what string, at dslToken) ([]string, error) {
+	var result []string
+	for i := 0; ; i += 2 {
+		w, err := p.word(tokens, i, what, at)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, w)
+		if i+1 >= len(tokens) {
+			return result, nil
+		}
+		if tokens[i+1].kind != dslComma {
+			return nil, p.errorf(tokens[i+1], "`,` expected, but %s found", tokens[i+1])
+		}
+	}
+}
+
+// id returns the text of a word token decoded by the codec.
+func (p *dslParser) id(tokens []dslToken, i int, what string, at dslToken) (string, error) {
+	w, err := p.word(tokens, i, what, at)
+	if err != nil {
+		return "", err
+	}
+	if err := p.decode(w); err != nil {
+		return "", &DSLError{tokens[i].line, tokens[i].column, err, fmt.Sprintf("invalid %s %q", what, w)}
+	}
+	return w, nil
+}
+
+// ids parses IDs separated by commas.
+func (p *dslParser) ids(tokens []dslToken, what string, at dslToken) ([]string, error) {
+	result, err := p.list(tokens, what, at)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		if _, err := p.id(tokens, 2*i, what, at); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// role returns the ID of a role referred to, which is checked by
+// checkRoles after parsing.
+func (p *dslParser) role(tokens []dslToken, i int, what string, at dslToken) (string, error) {
+	id, err := p.id(tokens, i, what, at)
+	if err != nil {
+		return "", err
+	}
+	p.roles = append(p.roles, tokens[i])
+	return id, nil
+}
+
+// roleIDs parses IDs of roles referred to separated by commas.
+func (p *dslParser) roleIDs(tokens []dslToken, what string, at dslToken) ([]string, error) {
+	result, err := p.ids(tokens, what, at)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		p.roles = append(p.roles, tokens[2*i])
+	}
+	return result, nil
+}
+
+// checkRoles reports every role referred to but not declared.
+func (p *dslParser) checkRoles() error {
+	var errs []error
+	for _, t := range p.roles {
+		if _, ok := p.policy.Roles[t.text]; !ok {
+			errs = append(errs, &DSLError{t.line, t.column, &RoleNotFoundError[string]{t.text},
+				"not declared by a `role` block"})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *dslParser) integer(tokens []dslToken, i int, what string, at dslToken) (int, error) {
+	w, err := p.word(tokens, i, what, at)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(w)
+	if err != nil || n < 0 {
+		return 0, p.errorf(tokens[i], "%s should be a number, but %q found", what, w)
+	}
+	return n, nil
+}
+
+// period parses the options `from <time>` and `until <time>`.
+func (p *dslParser) period(tokens []dslToken) (period Period, err error) {
+	for i := 0; i < len(tokens); i += 2 {
+		var t *time.Time
+		switch {
+		case keyword(tokens[i], "from"):
+			t = &period.NotBefore
+		case keyword(tokens[i], "until"):
+			t = &period.NotAfter
+		default:
+			return period, p.errorf(tokens[i], "`from` or `until` expected, but %s found", tokens[i])
+		}
+		w, err := p.word(tokens, i+1, "time", tokens[i])
+		if err != nil {
+			return period, err
+		}
+		if *t, err = time.Parse(time.RFC3339Nano, w); err != nil {
+			return period, &DSLError{tokens[i+1].line, tokens[i+1].column, err, "RFC 3339 time expected"}
+		}
+	}
+	return period, nil
+}
+
+func (p *dslParser) parse() error {
+	for {
+		p.skipEnds()
+		t := p.peek()
+		var err error
+		switch {
+		case t.kind == dslEOF:
+			return nil
+		case keyword(t, "role"):
+			err = p.parseRole()
+		case keyword(t, "subject"):
+			err = p.parseSubject()
+		case keyword(t, "ssd"), keyword(t, "dsd"):
+			err = p.parseSoD()
+		default:
+			err = p.errorf(t, "`role`, `subject`, `ssd` or `dsd` expected, but %s found", t)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// block parses `{ statements }`, calling `f` with each statement.
+func (p *dslParser) block(f func(tokens []dslToken) error) error {
+	if p.peek().kind != dslOpen {
+		return nil
+	}
+	p.next()
+	for {
+		p.skipEnds()
+		t := p.peek()
+		switch t.kind {
+		case dslClose:
+			p.next()
+			if end := p.peek(); end.kind != dslEnd && end.kind != dslEOF {
+				return p.errorf(end, "end of statement expected, but %s found", end)
+			}
+			return nil
+		case dslEOF:
+			return p.errorf(t, "`}` expected")
+		case dslOpen:
+			return p.errorf(t, "unexpected `{`")
+		}
+		tokens := p.statement()
+		if tokens[0].kind != dslWord || tokens[0].quoted {
+			return p.errorf(tokens[0], "statement expected, but %s found", tokens[0])
+		}
+		if err := f(tokens); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *dslParser) parseRole() error {
+	head := p.statement()
+	id, err := p.id(head, 1, "role", head[0])
+	if err != nil {
+		return err
+	}
+	if _, ok := p.policy.Roles[id]; ok {
+		return p.errorf(head[1], "role %q is declared twice", id)
+	}
+	pr := PolicyRole[string]{}
+	if len(head) > 2 {
+		if !keyword(head[2], "extends") {
+			return p.errorf(head[2], "`extends` or `{` expected, but %s found", head[2])
+		}
+		if pr.Parents, err = p.roleIDs(head[3:], "parent", head[2]); err != nil {
+			return err
+		}
+	}
+	err = p.block(func(tokens []dslToken) error {
+		return p.roleStatement(id, &pr, tokens)
+	})
+	p.policy.Roles[id] = pr
+	return err
+}
+
+func (p *dslParser) roleStatement(id string, pr *PolicyRole[string], tokens []dslToken) error {
+	head := tokens[0]
+	switch head.text {
+	case "grant":
+		return p.parseGrant(id, pr, tokens)
+	case "deny":
+		return &DSLError{head.line, head.column, ErrDSLUnsupported, "`deny`, goRBAC has no negative permissions"}
+	case "parent":
+		parent, err := p.role(tokens, 1, "parent", head)
+		if err != nil {
+			return err
+		}
+		period, err := p.period(tokens[2:])
+		if err != nil {
+			return err
+		}
+		pr.Parents = append(pr.Parents, parent)
+		p.addPeriod(ExpiryParent, id, parent, period)
+	case "name", "description":
+		w, err := p.word(tokens, 1, head.text, head)
+		if err != nil {
+			return err
+		}
+		if len(tokens) > 2 {
+			return p.errorf(tokens[2], "end of statement expected, but %s found", tokens[2])
+		}
+		if head.text == "name" {
+			pr.Metadata.Name = w
+		} else {
+			pr.Metadata.Description = w
+		}
+	case "tag":
+		tags, err := p.list(tokens[1:], "tag", head)
+		if err != nil {
+			return err
+		}
+		pr.Metadata.Tags = append(pr.Metadata.Tags, tags...)
+	case "attribute":
+		key, err := p.word(tokens, 1, "attribute", head)
+		if err != nil {
+			return err
+		}
+		value, err := p.word(tokens, 2, "value", head)
+		if err != nil {
+			return err
+		}
+		if len(tokens) > 3 {
+			return p.errorf(tokens[3], "end of statement expected, but %s found", tokens[3])
+		}
+		if pr.Metadata.Attributes == nil {
+			pr.Metadata.Attributes = make(map[string]string)
+		}
+		pr.Metadata.Attributes[key] = value
+	case "limit":
+		if len(tokens) < 2 {
+			return p.errorf(head, "`holders` or `parents` expected")
+		}
+		l := p.policy.Limits[id]
+		for i := 1; i < len(tokens); i += 2 {
+			n, err := p.integer(tokens, i+1, "limit", tokens[i])
+			if err != nil {
+				return err
+			}
+			switch {
+			case keyword(tokens[i], "holders"):
+				l.Holders = n
+			case keyword(tokens[i], "parents"):
+				l.Parents = n
+			default:
+				return p.errorf(tokens[i], "`holders` or `parents` expected, but %s found", tokens[i])
+			}
+		}
+		if p.policy.Limits == nil {
+			p.policy.Limits = make(map[string]Limit)
+		}
+		p.policy.Limits[id] = l
+	default:
+		return p.errorf(head, "unknown statement %q", head.text)
+	}
+	return nil
+}
+
+// dslKinds are the kinds of permissions written after `grant`.
+var dslKinds = map[string]bool{
+	PermissionStd:      true,
+	PermissionLayer:    true,
+	PermissionResource: true,
+	PermissionMask:     true,
+	PermissionRegex:    true,
+}
+
+func (p *dslParser) parseGrant(id string, pr *PolicyRole[string], tokens []dslToken) error {
+	kind, i := PermissionStd, 1
+	if len(tokens) > 2 && !tokens[1].quoted && dslKinds[tokens[1].text] {
+		kind, i = tokens[1].text, 2
+	}
+	word := p.word
+	if kind == PermissionStd {
+		word = p.id
+	}
+	pid, err := word(tokens, i, "permission", tokens[0])
+	if err != nil {
+		return err
+	}
+	at := tokens[i]
+	i++
+	sep := "/"
+	switch kind {
+	case PermissionLayer:
+		if i < len(tokens) && keyword(tokens[i], "sep") {
+			if sep, err = p.word(tokens, i+1, "separator", tokens[i]); err != nil {
+				return err
+			}
+			i += 2
+		}
+	case PermissionMask:
+		actions, err := p.word(tokens, i, "actions", at)
+		if err != nil {
+			return err
+		}
+		pid += ":" + actions
+		i++
+	}
+	q, err := ParsePermission(kind, pid, sep)
+	if err != nil {
+		return &DSLError{at.line, at.column, err, fmt.Sprintf("invalid %s permission %q", kind, pid)}
+	}
+	period, err := p.period(tokens[i:])
+	if err != nil {
+		return err
+	}
+	pr.Permissions = append(pr.Permissions, PolicyPermission[string]{q})
+	p.addPeriod(ExpiryGrant, id, q.ID(), period)
+	return nil
+}
+
+func (p *dslParser) parseSubject() error {
+	head := p.statement()
+	subject, err := p.id(head, 1, "subject", head[0])
+	if err != nil {
+		return err
+	}
+	if len(head) > 2 {
+		return p.errorf(head[2], "`{` expected, but %s found", head[2])
+	}
+	if p.policy.Subjects == nil {
+		p.policy.Subjects = make(map[string][]string)
+	}
+	if _, ok := p.policy.Subjects[subject]; ok {
+		return p.errorf(head[1], "subject %q is declared twice", subject)
+	}
+	p.policy.Subjects[subject] = []string{}
+	return p.block(func(tokens []dslToken) error {
+		if !keyword(tokens[0], "assign") {
+			return p.errorf(tokens[0], "`assign` expected, but %s found", tokens[0])
+		}
+		id, err := p.role(tokens, 1, "role", tokens[0])
+		if err != nil {
+			return err
+		}
+		period, err := p.period(tokens[2:])
+		if err != nil {
+			return err
+		}
+		p.policy.Subjects[subject] = append(p.policy.Subjects[subject], id)
+		p.addPeriod(ExpiryAssignment, subject, id, period)
+		return nil
+	})
+}
+
+func (p *dslParser) parseSoD() error {
+	tokens := p.statement()
+	name, err := p.word(tokens, 1, "name", tokens[0])
+	if err != nil {
+		return err
+	}
+	n, err := p.integer(tokens, 2, "cardinality", tokens[0])
+	if err != nil {
+		return err
+	}
+	roles, err := p.roleIDs(tokens[3:], "role", tokens[0])
+	if err != nil {
+		return err
+	}
+	c := SoDConstraint[string]{name, roles, n}
+	if tokens[0].text == "ssd" {
+		p.policy.SSD = append(p.policy.SSD, c)
+	} else {
+		p.policy.DSD = append(p.policy.DSD, c)
+	}
+	return nil
+}
+
+func (p *dslParser) addPeriod(kind, id, target string, period Period) {
+	if !period.IsZero() {
+		p.policy.Periods = append(p.policy.Periods, Expiry[string]{kind, id, target, period})
+	}
+}
+
+// FormatDSL writes the policy `p` as a policy text in the canonical
+// format: IDs encoded by the codec, roles, subjects and constraints
+// sorted, one statement a line and blocks indented by a tab. ParseDSL
+// reads it back to the same policy.
+func FormatDSL[T comparable](w io.Writer, policy *Policy[T], codec IDCodec[T]) error {
+	p, err := EncodePolicy(policy, codec)
+	if err != nil {
+		return err
+	}
+	periods := make(map[string]map[[2]string]Period)
+	for _, e := range p.Periods {
+		if periods[e.Kind] == nil {
+			periods[e.Kind] = make(map[[2]string]Period)
+		}
+		periods[e.Kind][[2]string{e.ID, e.Target}] = e.Period
+	}
+	var b strings.Builder
+	ids := keys(p.Roles)
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := formatRole(&b, id, p.Roles[id], p.Limits[id], periods); err != nil {
+			return err
+		}
+	}
+	subjects := keys(p.Subjects)
+	sort.Strings(subjects)
+	for _, subject := range subjects {
+		fmt.Fprintf(&b, "subject %s {\n", dslQuote(subject))
+		for _, id := range p.Subjects[subject] {
+			fmt.Fprintf(&b, "\tassign %s%s\n", dslQuote(id), formatPeriod(periods[ExpiryAssignment][[2]string{subject, id}]))
+		}
+		b.WriteString("}\n\n")
+	}
+	for _, sod := range []struct {
+		keyword     string
+		constraints []SoDConstraint[string]
+	}{{"ssd", p.SSD}, {"dsd", p.DSD}} {
+		for _, c := range sod.constraints {
+			roles := make([]string, len(c.Roles))
+			for i, id := range c.Roles {
+				roles[i] = dslQuote(id)
+			}
+			fmt.Fprintf(&b, "%s %s %d %s\n", sod.keyword, dslQuote(c.Name), c.Cardinality, strings.Join(roles, ", "))
+		}
+	}
+	text := strings.TrimRight(b.String(), "\n")
+	if text == "" {
+		return nil
+	}
+	_, err = io.WriteString(w, text+"\n")
+	return err
+}
+
+func formatRole(b *strings.Builder, id string, pr PolicyRole[string], l Limit, periods map[string]map[[2]string]Period) error {
+	var extends, lines []string
+	m := pr.Metadata
+	if m.Name != "" {
+		lines = append(lines, "name "+dslQuote(m.Name))
+	}
+	if m.Description != "" {
+		lines = append(lines, "description "+dslQuote(m.Description))
+	}
+	if len(m.Tags) > 0 {
+		tags := make([]string, len(m.Tags))
+		for i, tag := range m.Tags {
+			tags[i] = dslQuote(tag)
+		}
+		lines = append(lines, "tag "+strings.Join(tags, ", "))
+	}
+	attributes := keys(m.Attributes)
+	sort.Strings(attributes)
+	for _, key := range attributes {
+		lines = append(lines, "attribute "+dslQuote(key)+" "+dslQuote(m.Attributes[key]))
+	}
+	for _, parent := range pr.Parents {
+		if period, ok := periods[ExpiryParent][[2]string{id, parent}]; ok {
+			lines = append(lines, "parent "+dslQuote(parent)+formatPeriod(period))
+		} else {
+			extends = append(extends, dslQuote(parent))
+		}
+	}
+	for _, q := range pr.Permissions {
+		line, err := formatGrant(q.Permission)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		lines = append(lines, line+formatPeriod(periods[ExpiryGrant][[2]string{id, q.ID()}]))
+	}
+	if l.Holders != 0 || l.Parents != 0 {
+		line := "limit"
+		if l.Holders != 0 {
+			line += fmt.Sprintf(" holders %d", l.Holders)
+		}
+		if l.Parents != 0 {
+			line += fmt.Sprintf(" parents %d", l.Parents)
+		}
+		lines = append(lines, line)
+	}
+	b.WriteString("role " + dslQuote(id))
+	if len(extends) > 0 {
+		b.WriteString(" extends " + strings.Join(extends, ", "))
+	}
+	if len(lines) > 0 {
+		b.WriteString(" {\n\t" + strings.Join(lines, "\n\t") + "\n}")
+	}
+	b.WriteString("\n\n")
+	return nil
+}
+
+func formatGrant(p Permission[string]) (string, error) {
+	kind, err := permissionKind(p)
+	if err != nil {
+		return "", err
+	}
+	switch q := p.(type) {
+	case StdPermission[string]:
+		if dslKinds[q.SID] {
+			return "grant std " + dslQuote(q.SID), nil
+		}
+		return "grant " + dslQuote(q.SID), nil
+	case LayerPermission:
+		if q.Sep != "/" {
+			return "grant layer " + dslQuote(q.SID) + " sep " + dslQuote(q.Sep), nil
+		}
+	case MaskPermission:
+		actions, err := q.Actions.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return "grant mask " + dslQuote(q.SID) + " " + dslQuote(string(actions)), nil
+	}
+	return "grant " + kind + " " + dslQuote(p.ID()), nil
+}
+
+func formatPeriod(period Period) string {
+	var s string
+	if !period.NotBefore.IsZero() {
+		s += " from " + period.NotBefore.Format(time.RFC3339Nano)
+	}
+	if !period.NotAfter.IsZero() {
+		s += " until " + period.NotAfter.Format(time.RFC3339Nano)
+	}
+	return s
+}
+
+// dslQuote quotes `s` if it can't be written as a word.
+func dslQuote(s string) string {
+	if s == "" || strings.ContainsAny(s, dslSpecial) || strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,178 @@
+package gorbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDSLRoundTrip(t *testing.T) {
+	r := New[string]()
+	reports, err := NewRegexPermission(`^report-.*$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor := NewRole("editor")
+	editor.Metadata = Metadata{
+		Name:        "Editor",
+		Description: "Writes articles; reviews \"drafts\"",
+		Tags:        []string{"staff", "content team"},
+		Attributes:  map[string]string{"team": "news", "#": ""},
+	}
+	for _, p := range []Permission[string]{
+		NewPermission("write"),
+		NewPermission("mask"),
+		NewLayerPermission("articles/edit", "/"),
+		NewLayerPermission("shop::orders", "::"),
+		NewResourcePermission("document", ResourceAny, "edit"),
+		NewMaskPermission("comment", ActionRead|ActionUpdate),
+		reports,
+	} {
+		assert(t, editor.Assign(p))
+	}
+	assert(t, r.Add(editor))
+	assert(t, r.Add(NewRole("reader")))
+	assert(t, r.Add(NewRole("auditor")))
+	assert(t, r.Add(NewRole("head of news")))
+	assert(t, r.SetParent("editor", "reader"))
+	assert(t, r.SetParent("head of news", "editor"))
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2030, 1, 1, 12, 30, 0, 5, time.FixedZone("", 3600))
+	assert(t, r.AssignWithin("reader", NewPermission("read"), Period{NotBefore: from, NotAfter: until}))
+	assert(t, r.SetParentWithin("auditor", "reader", Period{NotAfter: until}))
+	assert(t, r.AssignRoleWithin("alice", "editor", Period{NotBefore: from}))
+	assert(t, r.AssignRole("bob", "auditor"))
+	assert(t, r.AssignRole("bob", "reader"))
+	assert(t, r.AddSSD(SoDConstraint[string]{"audit", []string{"editor", "auditor"}, 2}))
+	assert(t, r.AddDSD(SoDConstraint[string]{"session", []string{"reader", "auditor"}, 2}))
+	assert(t, r.SetLimit("auditor", Limit{Holders: 1, Parents: 2}))
+
+	policy := ExportPolicy(r)
+	var buf bytes.Buffer
+	assert(t, FormatDSL(&buf, policy, StringCodec{}))
+	parsed, err := ParseDSL(bytes.NewReader(buf.Bytes()), StringCodec{})
+	if err != nil {
+		t.Fatalf("%s\n%s", err, buf.String())
+	}
+	loaded, err := ImportPolicy(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, ExportPolicy(loaded)) {
+		t.Fatalf("The policy expected to be loaded from\n%s", buf.String())
+	}
+	expected, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, got) {
+		t.Fatalf("%s expected, but %s got", expected, got)
+	}
+	var again bytes.Buffer
+	assert(t, FormatDSL(&again, parsed, StringCodec{}))
+	if again.String() != buf.String() {
+		t.Fatalf("The format expected to be stable, but\n%s\ngot\n%s", buf.String(), again.String())
+	}
+}
+
+func TestFormatDSL(t *testing.T) {
+	text := `# the editors
+role editor extends reader { grant layer articles/edit ; grant write
+  tag staff
+}
+role "reader" {
+	grant mask comment "read | update" until 2030-01-01T00:00:00Z
+}
+role auditor { parent reader from 2024-01-01T00:00:00Z; limit parents 1 holders 1 }
+ssd audit 2 editor,auditor
+subject bob { assign editor }
+`
+	expected := `role auditor {
+	parent reader from 2024-01-01T00:00:00Z
+	limit holders 1 parents 1
+}
+
+role editor extends reader {
+	tag staff
+	grant layer articles/edit
+	grant write
+}
+
+role reader {
+	grant mask comment read|update until 2030-01-01T00:00:00Z
+}
+
+subject bob {
+	assign editor
+}
+
+ssd audit 2 editor, auditor
+`
+	p, err := ParseDSL(strings.NewReader(text), StringCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	assert(t, FormatDSL(&buf, p, StringCodec{}))
+	if buf.String() != expected {
+		t.Fatalf("%s expected, but %s got", expected, buf.String())
+	}
+}
+
+func TestParseDSLErrors(t *testing.T) {
+	cases := []struct {
+		text         string
+		line, column int
+		expected     error
+	}{
+		{"role editor {\n\tgrant write\n\tdeny articles/delete\n}", 3, 2, ErrDSLUnsupported},
+		{"role editor {\n\tgrant write", 2, 13, ErrDSLSyntax},
+		{"role editor\nrole editor", 2, 6, ErrDSLSyntax},
+		{"role editor inherits reader", 1, 13, ErrDSLSyntax},
+		{"role editor extends reader author", 1, 28, ErrDSLSyntax},
+		{"role a {\n  grant mask comment fly\n}", 2, 14, ErrUnknownAction},
+		{"role a {\n  grant x until tomorrow\n}", 2, 17, nil},
+		{"role a { grant x\n\"unterminated }", 2, 1, ErrDSLSyntax},
+		{"user alice", 1, 1, ErrDSLSyntax},
+		{"ssd audit two a, b", 1, 11, ErrDSLSyntax},
+		{"role a { limit holders }", 1, 16, ErrDSLSyntax},
+		{"role a {}\nrole b extends a, missing {}", 2, 19, ErrRoleNotExist},
+		{"role a {\n\tparent missing until 2025-01-01T00:00:00Z\n}", 2, 9, ErrRoleNotExist},
+		{"role a {}\nsubject alice {\n  assign missing\n}", 3, 10, ErrRoleNotExist},
+		{"role a {}\ndsd teller 2 a, missing", 2, 17, ErrRoleNotExist},
+	}
+	for _, c := range cases {
+		_, err := ParseDSL(strings.NewReader(c.text), StringCodec{})
+		var e *DSLError
+		if !errors.As(err, &e) {
+			t.Fatalf("A DSLError expected for %q, but %v got", c.text, err)
+		}
+		if e.Line != c.line || e.Column != c.column {
+			t.Fatalf("%d:%d expected for %q, but %s got", c.line, c.column, c.text, err)
+		}
+		if c.expected != nil && !errors.Is(err, c.expected) {
+			t.Fatalf("%s expected for %q, but %s got", c.expected, c.text, err)
+		}
+	}
+
+	// every undeclared role is reported
+	_, err := ParseDSL(strings.NewReader("role b extends x {}\nssd s 2 b, y"), StringCodec{})
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+		t.Fatalf("Two errors expected, but %v got", err)
+	}
+	for i, c := range [][2]int{{1, 16}, {2, 12}} {
+		var e *DSLError
+		if !errors.As(joined.Unwrap()[i], &e) || e.Line != c[0] || e.Column != c[1] {
+			t.Fatalf("%d:%d expected, but %v got", c[0], c[1], joined.Unwrap()[i])
+		}
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"github.com/mikespook/gorbac/v3"
 )
 
-// myRole is a custom role that embeds the standard gorbac.Role
-// and adds additional fields
+// myRole is a custom role that embeds the standard gorbac.StdRole
+// and adds additional fields and behaviour
 type myRole struct {
-	gorbac.Role[string]  // Embed the standard role
-	Label       string
-	Description string
+	*gorbac.StdRole[string] // Embed the standard role
+	Label                   string
+	Description             string
+	// external lists permissions granted by another system
+	external map[string]bool
 }
 
 // NewMyRole creates a new custom role with additional properties
@@ -20,40 +22,41 @@ func NewMyRole(name string) *myRole {
 	// loading extra properties by `name`.
 	label, desc := loadByName(name)
 	return &myRole{
-		Role:        gorbac.NewRole(name),  // Create the standard role
+		StdRole:     gorbac.NewRole(name), // Create the standard role
 		Label:       label,
 		Description: desc,
+		external:    loadExternal(name),
 	}
 }
 
+// Permit consults the external list before the assigned permissions
+func (role *myRole) Permit(p gorbac.Permission[string]) bool {
+	return role.external[p.ID()] || role.StdRole.Permit(p)
+}
+
 func loadByName(name string) (label, description string) {
 	// loading data from storages or somewhere
 	return name + " for testing", "This is the description for " + name
 }
 
+func loadExternal(name string) map[string]bool {
+	// loading data from another system
+	return map[string]bool{"external-" + name: true}
+}
+
 func main() {
 	rbac := gorbac.New[string]()
 	r1 := NewMyRole("role-1")
 	r2 := NewMyRole("role-2")
 	r3 := NewMyRole("role-3")
 	r4 := NewMyRole("role-4")
-	
-	// Add roles to RBAC - we need to pass the embedded Role part
-	if err := rbac.Add(r1.Role); err != nil {
-		fmt.Printf("Error: %s", err)
-		return
-	}
-	if err := rbac.Add(r2.Role); err != nil {
-		fmt.Printf("Error: %s", err)
-		return
-	}
-	if err := rbac.Add(r3.Role); err != nil {
-		fmt.Printf("Error: %s", err)
-		return
-	}
-	if err := rbac.Add(r4.Role); err != nil {
-		fmt.Printf("Error: %s", err)
-		return
+
+	// Add roles to RBAC, they are kept as *myRole
+	for _, r := range []*myRole{r1, r2, r3, r4} {
+		if err := rbac.Add(r); err != nil {
+			fmt.Printf("Error: %s", err)
+			return
+		}
 	}
 
 	if err := rbac.SetParents("role-1", []string{"role-2", "role-3"}); err != nil {
@@ -71,9 +74,18 @@ func main() {
 		fmt.Printf("Error: %s", err)
 		return
 	}
-	
-	// Note: In this simple example, we're not demonstrating access to the custom fields
-	// In a real application, you would maintain a separate map of custom roles
-	fmt.Printf("Role ID: %s\nParents: %v\n", role.ID, parents)
-}
 
+	// The custom fields are available through the original type
+	r, ok := role.(*myRole)
+	if !ok {
+		fmt.Printf("Error: unexpected role type %T", role)
+		return
+	}
+	fmt.Printf("Role ID: %s\nLabel: %s\nDescription: %s\nParents: %v\n",
+		r.ID(), r.Label, r.Description, parents)
+
+	// role-1 inherits the external permission of role-4 through role-3
+	if rbac.IsGranted("role-1", gorbac.NewPermission("external-role-4"), nil) {
+		fmt.Println("role-1 has been granted external-role-4")
+	}
+}
@@ -0,0 +1,204 @@
+package gorbac
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrInvalidExpectation occurred if an expectation can't be parsed
+	ErrInvalidExpectation = errors.New("Invalid expectation")
+)
+
+// ParsePermission parses a permission of `kind` from its `id`:
+// PermissionStd, PermissionLayer split by `sep`, PermissionResource as
+// `type:resource:action`, PermissionMask as `resource:read|update`, or
+// PermissionRegex as a pattern.
+func ParsePermission(kind, id, sep string) (Permission[string], error) {
+	switch kind {
+	case "", PermissionStd:
+		return NewPermission(id), nil
+	case PermissionLayer:
+		return NewLayerPermission(id, sep), nil
+	case PermissionResource:
+		return ParseResourcePermission(id)
+	case PermissionMask:
+		i := strings.LastIndex(id, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q should be resource:actions", ErrUnsupportedPermission, id)
+		}
+		actions, err := ParseAction(id[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		return NewMaskPermission(id[:i], actions), nil
+	case PermissionRegex:
+		return NewRegexPermission(id)
+	}
+	return nil, fmt.Errorf("%w: kind %q", ErrUnsupportedPermission, kind)
+}
+
+// Expectation is whether a role is expected to be granted a permission.
+type Expectation[T comparable] struct {
+	// Line is the line number in the expectation file
+	Line       int
+	Role       T
+	Permission Permission[T]
+	Expected   bool
+	// Attributes are passed to the assertion of the expectation
+	Attributes map[string]string
+}
+
+// ParseExpectations reads expectations, one a line:
+//
+//	# role, permission, expected[, attribute=value...]
+//	editor, add-text, allow
+//	editor, articles/edit/1, allow, kind=layer
+//	photographer, add-text, deny, owner=true
+//
+// Expected is allow, deny, true or false. Roles and permissions are
+// decoded by the codec. The attributes kind and sep decode the
+// permission as DecodePermission does, "/" by default, and the others
+// are kept for the assertion. Lines starting with "#" are comments.
+func ParseExpectations[T comparable](r io.Reader, codec IDCodec[T]) ([]Expectation[T], error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var result []Expectation[T]
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) < 3 {
+			return nil, fmt.Errorf("%w: line %d: role, permission and expected needed", ErrInvalidExpectation, line)
+		}
+		e := Expectation[T]{Line: line}
+		if e.Role, err = codec.Decode(strings.TrimSpace(record[0])); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidExpectation, line, err)
+		}
+		switch strings.ToLower(strings.TrimSpace(record[2])) {
+		case "allow", "true":
+			e.Expected = true
+		case "deny", "false":
+		default:
+			return nil, fmt.Errorf("%w: line %d: %q should be allow or deny", ErrInvalidExpectation, line, record[2])
+		}
+		kind, sep := PermissionStd, "/"
+		for _, attr := range record[3:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(attr), "=")
+			if !ok {
+				return nil, fmt.Errorf("%w: line %d: %q should be attribute=value", ErrInvalidExpectation, line, attr)
+			}
+			switch key {
+			case "kind":
+				kind = value
+			case "sep":
+				sep = value
+			default:
+				if e.Attributes == nil {
+					e.Attributes = make(map[string]string)
+				}
+				e.Attributes[key] = value
+			}
+		}
+		if e.Permission, err = DecodePermission(codec, kind, strings.TrimSpace(record[1]), sep); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidExpectation, line, err)
+		}
+		result = append(result, e)
+	}
+}
+
+// AssertionFactory returns the assertion for the attributes of an
+// expectation, or nil for no assertion.
+type AssertionFactory[T comparable] func(attributes map[string]string) AssertionFunc[T]
+
+// Mismatch is an expectation the policy doesn't meet.
+type Mismatch[T comparable] struct {
+	Expectation[T]
+	// Explanation tells why the permission is granted or not,
+	// it's nil if the role doesn't exist
+	Explanation *Explanation[T]
+	// Asserted is false if the assertion denied a granted permission
+	Asserted bool
+}
+
+func (m Mismatch[T]) String() string {
+	expected, got := "allow", "deny"
+	if !m.Expected {
+		expected, got = got, expected
+	}
+	reason := "the role doesn't exist"
+	switch e := m.Explanation; {
+	case e == nil:
+	case e.Granted && !m.Asserted:
+		reason = fmt.Sprintf("granted by %s (%v) but denied by the assertion", joinIDs(e.Path, " -> "), e.Grant)
+	case e.Granted:
+		reason = fmt.Sprintf("granted by %s (%v)", joinIDs(e.Path, " -> "), e.Grant)
+	default:
+		reason = "neither the role nor its ancestors hold a matching permission"
+	}
+	return fmt.Sprintf("line %d: %v %v expected %s, but %s: %s",
+		m.Line, m.Role, m.Permission.ID(), expected, got, reason)
+}
+
+// CheckExpectations tests every expectation against the policy, with
+// the assertion made by `factory` from its attributes if `factory` isn't
+// nil, and returns the mismatches.
+func CheckExpectations[T comparable](rbac *RBAC[T], expectations []Expectation[T], factory AssertionFactory[T]) []Mismatch[T] {
+	var result []Mismatch[T]
+	for _, e := range expectations {
+		var assert AssertionFunc[T]
+		if factory != nil {
+			assert = factory(e.Attributes)
+		}
+		if rbac.IsGranted(e.Role, e.Permission, assert) == e.Expected {
+			continue
+		}
+		m := Mismatch[T]{Expectation: e}
+		m.Explanation, _ = Explain(rbac, e.Role, e.Permission)
+		m.Asserted = assert == nil || assert(rbac, e.Role, e.Permission)
+		result = append(result, m)
+	}
+	return result
+}
+
+// TestingT is the part of testing.TB used by ExpectPolicy.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+// ExpectPolicy tests the policy against the expectation file `name`
+// from a test, reporting every mismatch as an error:
+//
+//	func TestPolicy(t *testing.T) {
+//		gorbac.ExpectPolicy(t, rbac, "testdata/expectations.csv", gorbac.StringCodec{}, nil)
+//	}
+func ExpectPolicy[T comparable](t TestingT, rbac *RBAC[T], name string, codec IDCodec[T], factory AssertionFactory[T]) {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("%s", err)
+		return
+	}
+	defer f.Close()
+	expectations, err := ParseExpectations(f, codec)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+		return
+	}
+	for _, m := range CheckExpectations(rbac, expectations, factory) {
+		t.Errorf("%s: %s", name, m)
+	}
+}
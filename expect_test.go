@@ -0,0 +1,112 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recorder records the reports of ExpectPolicy
+type recorder struct {
+	errors []string
+	fatal  string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.fatal = fmt.Sprintf(format, args...)
+}
+
+const expectations = `# role, permission, expected[, attribute=value...]
+admin, read, allow
+admin, articles/edit/1, allow, kind=layer
+guest, write, allow
+editor, read, deny
+editor, write, allow, owner=false
+nobody, read, allow
+`
+
+func TestExpectPolicy(t *testing.T) {
+	r := prepareQuery(t)
+	assert(t, roleOf(t, r, "editor").Assign(NewLayerPermission("articles/edit", "/")))
+	name := filepath.Join(t.TempDir(), "expectations.csv")
+	if err := os.WriteFile(name, []byte(expectations), 0644); err != nil {
+		t.Fatal(err)
+	}
+	owner := func(attributes map[string]string) AssertionFunc[string] {
+		if attributes["owner"] != "false" {
+			return nil
+		}
+		return func(*RBAC[string], string, Permission[string]) bool {
+			return false
+		}
+	}
+	var rec recorder
+	ExpectPolicy(&rec, r, name, StringCodec{}, owner)
+	if rec.fatal != "" {
+		t.Fatal(rec.fatal)
+	}
+	expected := []string{
+		"line 4: guest write expected allow, but deny: neither the role nor its ancestors hold a matching permission",
+		"line 5: editor read expected deny, but allow: granted by editor -> reader (read)",
+		"line 6: editor write expected allow, but deny: granted by editor (write) but denied by the assertion",
+		"line 7: nobody read expected allow, but deny: the role doesn't exist",
+	}
+	if len(rec.errors) != len(expected) {
+		t.Fatalf("%q expected, but %q got", expected, rec.errors)
+	}
+	for i, e := range expected {
+		if rec.errors[i] != name+": "+e {
+			t.Fatalf("%q expected, but %q got", e, rec.errors[i])
+		}
+	}
+
+	rec = recorder{}
+	ExpectPolicy(&rec, r, filepath.Join(t.TempDir(), "missing.csv"), StringCodec{}, nil)
+	if rec.fatal == "" {
+		t.Fatal("A missing file should be fatal")
+	}
+}
+
+func TestParseExpectations(t *testing.T) {
+	for _, data := range []string{
+		"admin, read\n",
+		"admin, read, maybe\n",
+		"admin, read, allow, owner\n",
+		"admin, read, allow, kind=unknown\n",
+	} {
+		if _, err := ParseExpectations(strings.NewReader(data), StringCodec{}); !errors.Is(err, ErrInvalidExpectation) {
+			t.Fatalf("%s expected for %q, but %v got", ErrInvalidExpectation, data, err)
+		}
+	}
+	es, err := ParseExpectations(strings.NewReader("a, comment:read|update, allow, kind=mask, owner=x\n"), StringCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es[0].Permission != NewMaskPermission("comment", ActionRead|ActionUpdate) || es[0].Attributes["owner"] != "x" {
+		t.Fatalf("A mask permission with an attribute expected, but %+v got", es[0])
+	}
+}
+
+func TestParseExpectationsCodec(t *testing.T) {
+	es, err := ParseExpectations(strings.NewReader("1, 42, allow\n"), IntCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es[0].Role != 1 || es[0].Permission != NewPermission(42) {
+		t.Fatalf("Integer IDs expected, but %+v got", es[0])
+	}
+	for _, data := range []string{"x, 42, allow\n", "1, a/b, allow, kind=layer\n"} {
+		if _, err := ParseExpectations(strings.NewReader(data), IntCodec[int]{}); !errors.Is(err, ErrInvalidExpectation) {
+			t.Fatalf("%s expected for %q, but %v got", ErrInvalidExpectation, data, err)
+		}
+	}
+}
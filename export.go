@@ -0,0 +1,218 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportOptions controls what WriteDOT and WriteMermaid render.
+type ExportOptions[T comparable] struct {
+	// Permissions renders the permissions assigned to each role as
+	// leaf nodes
+	Permissions bool
+	// Focus limits the graph to these roles, their ancestors and their
+	// descendants. All roles are rendered if it's empty.
+	Focus []T
+	// Codec encodes the IDs of the roles and the permissions, they're
+	// rendered by fmt.Sprint if it's nil
+	Codec IDCodec[T]
+}
+
+// exportGraph is the part of a policy to render, in a stable order.
+type exportGraph[T comparable] struct {
+	roles       []T
+	edges       []Edge[T]
+	timed       map[Edge[T]]bool
+	permissions map[T][]T
+	// cycle contains the roles and the edges of a circle inheritance
+	cycle      map[T]bool
+	cycleEdges map[Edge[T]]bool
+	// names are the encoded IDs of the roles and the permissions
+	names map[T]string
+}
+
+func buildExportGraph[T comparable](rbac *RBAC[T], opts ExportOptions[T]) (*exportGraph[T], error) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	g := &exportGraph[T]{
+		timed:       make(map[Edge[T]]bool),
+		permissions: make(map[T][]T),
+		cycle:       make(map[T]bool),
+		cycleEdges:  make(map[Edge[T]]bool),
+		names:       make(map[T]string),
+	}
+	view := make(map[T]struct{}, len(rbac.roles))
+	if len(opts.Focus) == 0 {
+		for id := range rbac.roles {
+			view[id] = empty
+		}
+	} else {
+		for _, id := range opts.Focus {
+			if _, ok := rbac.roles[id]; !ok {
+				continue
+			}
+			for rid := range rbac.ancestors(time.Time{}, id) {
+				view[rid] = empty
+			}
+			for rid := range rbac.descendants(id) {
+				view[rid] = empty
+			}
+		}
+	}
+	g.roles = keys(view)
+	sortIDs(g.roles)
+	for _, id := range g.roles {
+		for parent, period := range rbac.parents[id] {
+			if _, ok := view[parent]; !ok {
+				continue
+			}
+			e := Edge[T]{id, parent}
+			g.edges = append(g.edges, e)
+			g.timed[e] = !period.IsZero()
+		}
+		if opts.Permissions {
+			if role, ok := rbac.roles[id]; ok {
+				for _, p := range role.Permissions() {
+					g.permissions[id] = append(g.permissions[id], p.ID())
+				}
+				sortIDs(g.permissions[id])
+			}
+		}
+	}
+	sort.Slice(g.edges, func(i, j int) bool {
+		return fmt.Sprint(g.edges[i].Role, " ", g.edges[i].Parent) <
+			fmt.Sprint(g.edges[j].Role, " ", g.edges[j].Parent)
+	})
+	var cycle *CycleError[T]
+	if errors.As(inherCircle(rbac), &cycle) {
+		for i, id := range cycle.Path {
+			g.cycle[id] = true
+			if i > 0 {
+				g.cycleEdges[Edge[T]{cycle.Path[i-1], id}] = true
+			}
+		}
+	}
+	for _, id := range g.roles {
+		for _, nid := range append([]T{id}, g.permissions[id]...) {
+			name, err := encodeID(opts.Codec, nid)
+			if err != nil {
+				return nil, err
+			}
+			g.names[nid] = name
+		}
+	}
+	return g, nil
+}
+
+// WriteDOT renders the role hierarchy as a Graphviz DOT digraph.
+// Each role points to its parents, time-bounded edges are dashed, and a
+// circle inheritance found by InherCircle is drawn in red. Role nodes are
+// named "role:<id>" and permission nodes "permission:<id>", so the two
+// never collide; both are labelled by the bare ID.
+func WriteDOT[T comparable](w io.Writer, rbac *RBAC[T], opts ExportOptions[T]) error {
+	g, err := buildExportGraph(rbac, opts)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("digraph rbac {\n\trankdir=BT;\n\tnode [shape=ellipse];\n")
+	for _, id := range g.roles {
+		attrs := "label=" + dotID(g.names[id])
+		if g.cycle[id] {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(&b, "\t%s [%s];\n", dotID("role:"+g.names[id]), attrs)
+	}
+	for _, e := range g.edges {
+		var attrs []string
+		if g.timed[e] {
+			attrs = append(attrs, "style=dashed")
+		}
+		if g.cycleEdges[e] {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(&b, "\t%s -> %s", dotID("role:"+g.names[e.Role]), dotID("role:"+g.names[e.Parent]))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	for _, id := range g.roles {
+		for _, pid := range g.permissions[id] {
+			node := dotID("permission:" + g.names[pid])
+			fmt.Fprintf(&b, "\t%s [shape=box, label=%s];\n", node, dotID(g.names[pid]))
+			fmt.Fprintf(&b, "\t%s -> %s [style=dotted, arrowhead=none];\n", dotID("role:"+g.names[id]), node)
+		}
+	}
+	b.WriteString("}\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid renders the role hierarchy as a Mermaid flowchart.
+// Each role points to its parents, time-bounded edges are dotted, and a
+// circle inheritance found by InherCircle is drawn in red.
+func WriteMermaid[T comparable](w io.Writer, rbac *RBAC[T], opts ExportOptions[T]) error {
+	g, err := buildExportGraph(rbac, opts)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("flowchart BT\n")
+	nodes := make(map[T]string, len(g.roles))
+	for i, id := range g.roles {
+		nodes[id] = fmt.Sprintf("r%d", i)
+		fmt.Fprintf(&b, "\t%s([%s])\n", nodes[id], mermaidLabel(g.names[id]))
+	}
+	var links, red []string
+	for _, e := range g.edges {
+		arrow := "-->"
+		if g.timed[e] {
+			arrow = "-.->"
+		}
+		if g.cycleEdges[e] {
+			red = append(red, strconv.Itoa(len(links)))
+		}
+		links = append(links, fmt.Sprintf("\t%s %s %s\n", nodes[e.Role], arrow, nodes[e.Parent]))
+	}
+	n := 0
+	for _, id := range g.roles {
+		for _, pid := range g.permissions[id] {
+			node := fmt.Sprintf("p%d", n)
+			n++
+			fmt.Fprintf(&b, "\t%s[%s]\n", node, mermaidLabel(g.names[pid]))
+			links = append(links, fmt.Sprintf("\t%s --- %s\n", nodes[id], node))
+		}
+	}
+	b.WriteString(strings.Join(links, ""))
+	var cycle []string
+	for _, id := range g.roles {
+		if g.cycle[id] {
+			cycle = append(cycle, nodes[id])
+		}
+	}
+	if len(cycle) > 0 {
+		b.WriteString("\tclassDef cycle stroke:red,color:red\n")
+		fmt.Fprintf(&b, "\tclass %s cycle\n", strings.Join(cycle, ","))
+	}
+	if len(red) > 0 {
+		fmt.Fprintf(&b, "\tlinkStyle %s stroke:red\n", strings.Join(red, ","))
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// dotID quotes `name` as a DOT ID. Only `"` and `\` are escaped, any other
+// character, non-ASCII included, is kept as it is.
+func dotID(name string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+}
+
+func mermaidLabel(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, "#quot;") + `"`
+}
@@ -0,0 +1,111 @@
+package gorbac
+
+import (
+	"strings"
+	"testing"
+)
+
+func prepareExport(t *testing.T) *RBAC[string] {
+	r := New[string]()
+	for _, id := range []string{"admin", "editor", "reader", "guest"} {
+		role := NewRole(id)
+		assert(t, role.Assign(NewPermission(id+"-permission")))
+		assert(t, r.Add(role))
+	}
+	assert(t, r.SetParent("admin", "editor"))
+	assert(t, r.SetParentWithin("editor", "reader", Period{NotAfter: r.clock().Add(1e12)}))
+	return r
+}
+
+func TestWriteDOT(t *testing.T) {
+	r := prepareExport(t)
+	var b strings.Builder
+	assert(t, WriteDOT(&b, r, ExportOptions[string]{Permissions: true, Focus: []string{"editor"}}))
+	expected := `digraph rbac {
+	rankdir=BT;
+	node [shape=ellipse];
+	"role:admin" [label="admin"];
+	"role:editor" [label="editor"];
+	"role:reader" [label="reader"];
+	"role:admin" -> "role:editor";
+	"role:editor" -> "role:reader" [style=dashed];
+	"permission:admin-permission" [shape=box, label="admin-permission"];
+	"role:admin" -> "permission:admin-permission" [style=dotted, arrowhead=none];
+	"permission:editor-permission" [shape=box, label="editor-permission"];
+	"role:editor" -> "permission:editor-permission" [style=dotted, arrowhead=none];
+	"permission:reader-permission" [shape=box, label="reader-permission"];
+	"role:reader" -> "permission:reader-permission" [style=dotted, arrowhead=none];
+}
+`
+	if b.String() != expected {
+		t.Fatalf("%s expected, but %s got", expected, b.String())
+	}
+
+	assert(t, r.SetParent("reader", "admin"))
+	b.Reset()
+	assert(t, WriteDOT(&b, r, ExportOptions[string]{}))
+	for _, line := range []string{
+		`"role:admin" [label="admin", color=red];`,
+		`"role:admin" -> "role:editor" [color=red];`,
+		`"role:editor" -> "role:reader" [style=dashed, color=red];`,
+		`"role:guest" [label="guest"];`,
+	} {
+		if !strings.Contains(b.String(), "\t"+line+"\n") {
+			t.Fatalf("%s expected in %s", line, b.String())
+		}
+	}
+}
+
+func TestWriteDOTQuote(t *testing.T) {
+	r := New[string]()
+	role := NewRole("permission:read")
+	assert(t, role.Assign(NewPermission("read")))
+	assert(t, r.Add(role))
+	assert(t, r.Add(NewRole(`café "\"`)))
+	var b strings.Builder
+	assert(t, WriteDOT(&b, r, ExportOptions[string]{Permissions: true}))
+	for _, line := range []string{
+		`"role:café \"\\\"" [label="café \"\\\""];`,
+		`"role:permission:read" [label="permission:read"];`,
+		`"permission:read" [shape=box, label="read"];`,
+		`"role:permission:read" -> "permission:read" [style=dotted, arrowhead=none];`,
+	} {
+		if !strings.Contains(b.String(), "\t"+line+"\n") {
+			t.Fatalf("%s expected in %s", line, b.String())
+		}
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	r := prepareExport(t)
+	assert(t, r.Add(NewRole(`say "hi"`)))
+	assert(t, r.SetParent(`say "hi"`, "guest"))
+	var b strings.Builder
+	assert(t, WriteMermaid(&b, r, ExportOptions[string]{Focus: []string{"guest"}}))
+	expected := `flowchart BT
+	r0(["guest"])
+	r1(["say #quot;hi#quot;"])
+	r1 --> r0
+`
+	if b.String() != expected {
+		t.Fatalf("%s expected, but %s got", expected, b.String())
+	}
+
+	assert(t, r.SetParent("reader", "admin"))
+	b.Reset()
+	assert(t, WriteMermaid(&b, r, ExportOptions[string]{Focus: []string{"admin"}}))
+	expected = `flowchart BT
+	r0(["admin"])
+	r1(["editor"])
+	r2(["reader"])
+	r0 --> r1
+	r1 -.-> r2
+	r2 --> r0
+	classDef cycle stroke:red,color:red
+	class r0,r1,r2 cycle
+	linkStyle 0,1,2 stroke:red
+`
+	if b.String() != expected {
+		t.Fatalf("%s expected, but %s got", expected, b.String())
+	}
+}
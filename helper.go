@@ -1,6 +1,11 @@
 package gorbac
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
 
 // WalkHandler is a function defined by user to handle role
 type WalkHandler[T comparable] func(Role[T], []T) error
@@ -28,7 +33,12 @@ func Walk[T comparable](rbac *RBAC[T], h WalkHandler[T]) (err error) {
 // InherCircle returns an error when detecting any circle inheritance.
 func InherCircle[T comparable](rbac *RBAC[T]) (err error) {
 	rbac.mutex.Lock()
+	err = inherCircle(rbac)
+	rbac.mutex.Unlock()
+	return err
+}
 
+func inherCircle[T comparable](rbac *RBAC[T]) (err error) {
 	skipped := make(map[T]struct{}, len(rbac.roles))
 	var stack []T
 
@@ -37,23 +47,47 @@ func InherCircle[T comparable](rbac *RBAC[T]) (err error) {
 			break
 		}
 	}
-	rbac.mutex.Unlock()
 	return err
 }
 
 var (
+	// ErrFoundCircle occurred if a role inherits from itself
 	ErrFoundCircle = fmt.Errorf("Found circle")
 )
 
+// CycleError carries the path of a circle inheritance, starting and
+// ending with the same role. It wraps ErrFoundCircle.
+type CycleError[T comparable] struct {
+	Path []T
+}
+
+func (e *CycleError[T]) Error() string {
+	return fmt.Sprintf("%s: %s", ErrFoundCircle, joinIDs(e.Path, " -> "))
+}
+
+// joinIDs joins the IDs formatted by fmt.Sprint with `sep`.
+func joinIDs[T comparable](ids []T, sep string) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = fmt.Sprint(id)
+	}
+	return strings.Join(s, sep)
+}
+
+func (e *CycleError[T]) Unwrap() error {
+	return ErrFoundCircle
+}
+
 // https://en.wikipedia.org/wiki/Depth-first_search
 func dfs[T comparable](rbac *RBAC[T], id T, skipped map[T]struct{},
 	stack []T) error {
 	if _, ok := skipped[id]; ok {
 		return nil
 	}
-	for _, item := range stack {
+	for i, item := range stack {
 		if item == id {
-			return ErrFoundCircle
+			path := append(append([]T(nil), stack[i:]...), id)
+			return &CycleError[T]{path}
 		}
 	}
 	parents := rbac.parents[id]
@@ -99,3 +133,51 @@ func AllGranted[T comparable](rbac *RBAC[T], roles []T,
 	rbac.mutex.Unlock()
 	return
 }
+
+// CheckConstraints returns every static separation of duty constraint
+// and every role limit broken by the current policy, e.g. after the
+// constraints were declared on a loaded policy.
+func CheckConstraints[T comparable](rbac *RBAC[T]) (errs []error) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	for id := range rbac.roles {
+		for name, c := range rbac.ssd {
+			err := checkSoD(map[string]SoDConstraint[T]{name: c},
+				ErrSSDViolation, id, rbac.ancestors(time.Time{}, id))
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := rbac.checkParents(id); err != nil {
+			errs = append(errs, err)
+		}
+		l := rbac.limits[id]
+		if n := rbac.holders(id); l.Holders != 0 && n > l.Holders {
+			errs = append(errs, &CardinalityError[T]{
+				Err: ErrTooManyHolders, ID: id, Limit: l.Holders, Count: n,
+			})
+		}
+	}
+	for subject, roles := range rbac.subjects {
+		for name, c := range rbac.ssd {
+			err := checkSoD(map[string]SoDConstraint[T]{name: c},
+				ErrSSDViolation, subject, rbac.ancestors(time.Time{}, keys(roles)...))
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return
+}
+
+// sameRole returns true if `a` and `b` are the same role. Roles of types
+// which can't be compared are the same if they have the same ID.
+func sameRole[T comparable](a, b Role[T]) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return a.ID() == b.ID()
+	}
+	return a == b
+}
@@ -71,7 +71,7 @@ func TestWalk(t *testing.T) {
 		t.Errorf("Unexpected error: %s", err)
 	}
 	h := func(r Role[string], parents []string) error {
-		t.Logf("Role: %v", r.ID)
+		t.Logf("Role: %v", r.ID())
 		permissions := make([]string, 0)
 		for _, p := range r.Permissions() {
 			permissions = append(permissions, p.ID())
@@ -115,3 +115,29 @@ func BenchmarkInherNormal(b *testing.B) {
 		InherCircle(rbac)
 	}
 }
+
+func TestCycleError(t *testing.T) {
+	r := New[string]()
+	for _, id := range []string{"role-x", "role-y", "role-z", "role-w"} {
+		assert(t, r.Add(NewRole(id)))
+	}
+	assert(t, r.SetParent("role-w", "role-x"))
+	assert(t, r.SetParent("role-x", "role-y"))
+	assert(t, r.SetParent("role-y", "role-z"))
+	assert(t, r.SetParent("role-z", "role-x"))
+	err := InherCircle(r)
+	var cycle *CycleError[string]
+	if !errors.As(err, &cycle) || !errors.Is(err, ErrFoundCircle) {
+		t.Fatalf("CycleError needed, but %v got", err)
+	}
+	path := cycle.Path
+	if len(path) != 4 || path[0] != path[3] {
+		t.Fatalf("A closed path of three roles expected, but %v got", path)
+	}
+	for _, id := range path {
+		if id == "role-w" {
+			t.Fatalf("[role-w] is not a part of the circle: %v", path)
+		}
+	}
+	t.Log(err)
+}
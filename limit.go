@@ -0,0 +1,118 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTooManyHolders occurred if a role would be assigned to more
+	// subjects than its limit
+	ErrTooManyHolders = errors.New("Role has too many holders")
+	// ErrTooManyParents occurred if a role would have more parents than
+	// its limit
+	ErrTooManyParents = errors.New("Role has too many parents")
+	// ErrInvalidLimit occurred if a limit is negative
+	ErrInvalidLimit = errors.New("Invalid limit")
+)
+
+// Limit declares the cardinality of a role.
+// A zero value means unlimited.
+type Limit struct {
+	// Holders is the maximum number of subjects assigned to the role,
+	// assignments whose period is over aren't counted
+	Holders int `json:"holders,omitempty"`
+	// Parents is the maximum number of parents of the role
+	Parents int `json:"parents,omitempty"`
+}
+
+// CardinalityError describes which limit of a role was exceeded.
+type CardinalityError[T comparable] struct {
+	// Err is either ErrTooManyHolders or ErrTooManyParents
+	Err error
+	// ID is the role exceeding its limit
+	ID T
+	// Limit is the declared maximum
+	Limit int
+	// Count is the number of holders or parents exceeding the limit
+	Count int
+}
+
+func (e *CardinalityError[T]) Error() string {
+	return fmt.Sprintf("%s: %v has %d, at most %d allowed",
+		e.Err, e.ID, e.Count, e.Limit)
+}
+
+func (e *CardinalityError[T]) Unwrap() error {
+	return e.Err
+}
+
+// SetLimit declares the cardinality of the role `id`.
+// It is enforced by AssignRole and SetParent(s) from now on,
+// existing holders and parents are reported by CheckConstraints.
+// Negative limits are refused with ErrInvalidLimit.
+func (rbac *RBAC[T]) SetLimit(id T, l Limit) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.roles[id]; !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	if l.Holders < 0 || l.Parents < 0 {
+		return fmt.Errorf("%w: %+v of %v", ErrInvalidLimit, l, id)
+	}
+	if l == (Limit{}) {
+		delete(rbac.limits, id)
+	} else {
+		rbac.limits[id] = l
+	}
+	return nil
+}
+
+// GetLimit returns the cardinality of the role `id`.
+func (rbac *RBAC[T]) GetLimit(id T) (Limit, error) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	if _, ok := rbac.roles[id]; !ok {
+		return Limit{}, &RoleNotFoundError[T]{id}
+	}
+	return rbac.limits[id], nil
+}
+
+// holders returns the number of subjects assigned to the role `id`,
+// except the assignments whose period is over. Assignments starting
+// later are counted, as they'll hold the role.
+func (rbac *RBAC[T]) holders(id T) (n int) {
+	now := rbac.now()
+	for _, roles := range rbac.subjects {
+		if period, ok := roles[id]; ok && !period.Expired(now) {
+			n++
+		}
+	}
+	return
+}
+
+// checkHolders tests whether one more holder of the role `id`
+// exceeds its limit.
+func (rbac *RBAC[T]) checkHolders(id T) error {
+	l := rbac.limits[id]
+	if l.Holders == 0 {
+		return nil
+	}
+	if n := rbac.holders(id) + 1; n > l.Holders {
+		return &CardinalityError[T]{Err: ErrTooManyHolders, ID: id, Limit: l.Holders, Count: n}
+	}
+	return nil
+}
+
+// checkParents tests whether the parents of the role `id` exceed its
+// limit.
+func (rbac *RBAC[T]) checkParents(id T) error {
+	l := rbac.limits[id]
+	if l.Parents == 0 {
+		return nil
+	}
+	if n := len(rbac.parents[id]); n > l.Parents {
+		return &CardinalityError[T]{Err: ErrTooManyParents, ID: id, Limit: l.Parents, Count: n}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package gorbac
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimitHolders(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(NewRole("super-admin")))
+	if err := r.SetLimit("not-exist", Limit{Holders: 2}); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s needed", ErrRoleNotExist)
+	}
+	assert(t, r.SetLimit("super-admin", Limit{Holders: 2}))
+	if l, err := r.GetLimit("super-admin"); err != nil || l.Holders != 2 {
+		t.Fatalf("Unexpected limit: %v, %v", l, err)
+	}
+	assert(t, r.AssignRole("alice", "super-admin"))
+	assert(t, r.AssignRole("bob", "super-admin"))
+	// assigning again doesn't add a holder
+	assert(t, r.AssignRole("bob", "super-admin"))
+	err := r.AssignRole("carol", "super-admin")
+	if !errors.Is(err, ErrTooManyHolders) {
+		t.Fatalf("%s needed, but %v got", ErrTooManyHolders, err)
+	}
+	var cErr *CardinalityError[string]
+	if !errors.As(err, &cErr) || cErr.ID != "super-admin" || cErr.Limit != 2 || cErr.Count != 3 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assert(t, r.RevokeRole("alice", "super-admin"))
+	assert(t, r.AssignRole("carol", "super-admin"))
+	if err := r.SetLimit("super-admin", Limit{Holders: -1}); !errors.Is(err, ErrInvalidLimit) {
+		t.Fatalf("%s needed, but %v got", ErrInvalidLimit, err)
+	}
+}
+
+func TestLimitExpiredHolders(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New[string]()
+	r.SetClock(func() time.Time { return now })
+	assert(t, r.Add(NewRole("contractor")))
+	assert(t, r.SetLimit("contractor", Limit{Holders: 1}))
+	assert(t, r.AssignRoleWithin("alice", "contractor", Period{NotAfter: now.Add(time.Hour)}))
+	if err := r.AssignRole("bob", "contractor"); !errors.Is(err, ErrTooManyHolders) {
+		t.Fatalf("%s needed, but %v got", ErrTooManyHolders, err)
+	}
+	// the slot of an expired assignment is free
+	now = now.Add(2 * time.Hour)
+	assert(t, r.AssignRole("bob", "contractor"))
+	if err := r.AssignRole("alice", "contractor"); !errors.Is(err, ErrTooManyHolders) {
+		t.Fatalf("%s needed to renew, but %v got", ErrTooManyHolders, err)
+	}
+}
+
+func TestLimitParents(t *testing.T) {
+	r := New[string]()
+	for _, id := range []string{"role-x", "role-y", "role-z", "child"} {
+		assert(t, r.Add(NewRole(id)))
+	}
+	assert(t, r.SetLimit("child", Limit{Parents: 1}))
+	assert(t, r.SetParent("child", "role-x"))
+	if err := r.SetParent("child", "role-y"); !errors.Is(err, ErrTooManyParents) {
+		t.Fatalf("%s needed, but %v got", ErrTooManyParents, err)
+	}
+	if err := r.SetParents("child", []string{"role-y", "role-z"}); !errors.Is(err, ErrTooManyParents) {
+		t.Fatalf("%s needed, but %v got", ErrTooManyParents, err)
+	}
+	if parents, _ := r.GetParents("child"); len(parents) != 1 || parents[0] != "role-x" {
+		t.Fatalf("[child] should keep its parent, but %v got", parents)
+	}
+	// rebinding an existing parent is not a new parent
+	assert(t, r.SetParent("child", "role-x"))
+}
+
+func TestCheckConstraints(t *testing.T) {
+	r := New[string]()
+	for _, id := range []string{"requester", "approver", "admin", "child"} {
+		assert(t, r.Add(NewRole(id)))
+	}
+	assert(t, r.AssignRole("alice", "admin"))
+	assert(t, r.AssignRole("bob", "admin"))
+	assert(t, r.AssignRole("bob", "requester"))
+	assert(t, r.SetParents("child", []string{"requester", "admin"}))
+	if errs := CheckConstraints(r); len(errs) != 0 {
+		t.Fatalf("No violation expected, but %v got", errs)
+	}
+	assert(t, r.SetLimit("admin", Limit{Holders: 1}))
+	assert(t, r.SetLimit("child", Limit{Parents: 1}))
+	errs := CheckConstraints(r)
+	if len(errs) != 2 {
+		t.Fatalf("Two violations expected, but %v got", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrTooManyHolders) && !errors.Is(err, ErrTooManyParents) {
+			t.Fatalf("Unexpected violation: %v", err)
+		}
+	}
+	assert(t, r.RevokeRole("bob", "admin"))
+	assert(t, r.RemoveParent("child", "admin"))
+	if errs := CheckConstraints(r); len(errs) != 0 {
+		t.Fatalf("No violation expected, but %v got", errs)
+	}
+}
@@ -0,0 +1,287 @@
+package gorbac
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Kinds of conflicts reported by Merge
+const (
+	// ConflictRemovedRole is a role removed by one side but modified by
+	// the other
+	ConflictRemovedRole = "removed-role"
+	// ConflictGrant is a permission of a role, or the period of its
+	// grant, changed differently by both sides
+	ConflictGrant = "grant"
+	// ConflictEdge is the period of an inheritance edge changed
+	// differently by both sides
+	ConflictEdge = "edge"
+	// ConflictMetadata is the metadata of a role changed differently by
+	// both sides
+	ConflictMetadata = "metadata"
+)
+
+// Conflict is a change of both sides Merge can't reconcile.
+type Conflict[T comparable] struct {
+	Kind string `json:"kind"`
+	Role T      `json:"role"`
+	// Target is the permission ID of a ConflictGrant, or the parent of a
+	// ConflictEdge
+	Target T `json:"target,omitempty"`
+	// Message is for people, e.g. in a review, with the IDs printed by
+	// fmt.Sprint
+	Message string `json:"message"`
+}
+
+func (c Conflict[T]) String() string {
+	return fmt.Sprintf("%s %v: %s", c.Kind, c.Role, c.Message)
+}
+
+// Merge merges the changes of `ours` and `theirs` to their common
+// `base`: roles, their permissions and inheritance edges. A change made
+// by one side only is taken, and so is the same change made by both.
+//
+// Grants and edges are compared with their periods, and the metadata of
+// the roles as a whole.
+//
+// Conflicts are resolved in favour of `ours` and returned to be
+// reviewed: a role removed by one side while the other one changed its
+// permissions, edges or metadata, and a grant, the period of an edge or
+// the metadata of a role changed differently by both sides. A role
+// removed by theirs but changed by ours is kept with every grant and
+// edge of ours. If the merged edges make a circle inheritance, no policy
+// but a *CycleError is returned.
+//
+// The merged roles are StdRole. Subjects, constraints and limits are not
+// merged, declare them on the merged policy.
+func Merge[T comparable](base, ours, theirs *RBAC[T]) (*RBAC[T], []Conflict[T], error) {
+	b, o, t := snapshot(base), snapshot(ours), snapshot(theirs)
+	var conflicts []Conflict[T]
+
+	all := make(map[T]struct{})
+	for _, s := range []policySnapshot[T]{b, o, t} {
+		for id := range s.roles {
+			all[id] = empty
+		}
+	}
+	roles := make(map[T]struct{})
+	// kept are the roles removed by theirs but kept as ours changed them,
+	// their grants and edges are taken from ours
+	kept := make(map[T]struct{})
+	for id := range all {
+		_, inB := b.roles[id]
+		_, inO := o.roles[id]
+		_, inT := t.roles[id]
+		switch {
+		case inB && !inO && inT && modified(b, t, id):
+			conflicts = append(conflicts, Conflict[T]{
+				Kind: ConflictRemovedRole, Role: id,
+				Message: fmt.Sprintf("%v is removed by ours but modified by theirs", id),
+			})
+		case inB && inO && !inT && modified(b, o, id):
+			conflicts = append(conflicts, Conflict[T]{
+				Kind: ConflictRemovedRole, Role: id,
+				Message: fmt.Sprintf("%v is removed by theirs but modified by ours", id),
+			})
+			roles[id] = empty
+			kept[id] = empty
+		case merge3(inB, inO, inT):
+			roles[id] = empty
+		}
+	}
+
+	rbac := New[T]()
+	for id := range roles {
+		_, isKept := kept[id]
+		role := NewRole(id)
+		// a side without the role leaves its metadata unchanged
+		mb := metadataIn(b, id, Metadata{})
+		mo, mt := metadataIn(o, id, mb), metadataIn(t, id, mb)
+		switch {
+		case equalMetadata(mo, mb):
+			role.Metadata = mt
+		case !equalMetadata(mt, mb) && !equalMetadata(mt, mo):
+			conflicts = append(conflicts, Conflict[T]{
+				Kind: ConflictMetadata, Role: id,
+				Message: fmt.Sprintf("metadata of %v is changed by both sides", id),
+			})
+			fallthrough
+		default:
+			role.Metadata = mo
+		}
+		pids := make(map[T]struct{})
+		for _, s := range []policySnapshot[T]{b, o, t} {
+			for pid := range s.grants[id] {
+				pids[pid] = empty
+			}
+		}
+		for pid := range pids {
+			gb, gO, gt := grantIn(b, id, pid), grantIn(o, id, pid), grantIn(t, id, pid)
+			g := gO
+			switch {
+			case isKept:
+			case gb.equal(gO):
+				g = gt
+			case !gt.equal(gb) && !gt.equal(gO):
+				conflicts = append(conflicts, Conflict[T]{
+					Kind: ConflictGrant, Role: id, Target: pid,
+					Message: fmt.Sprintf("%v of %v is changed by both sides", pid, id),
+				})
+			}
+			if g.p == nil {
+				continue
+			}
+			role.index(g.p, g.period)
+		}
+		rbac.add(role)
+	}
+
+	seen := make(map[Edge[T]]struct{})
+	for _, s := range []policySnapshot[T]{b, o, t} {
+		for id, parents := range s.parents {
+			for parent := range parents {
+				_, okID := roles[id]
+				_, okParent := roles[parent]
+				if !okID || !okParent {
+					continue
+				}
+				if _, ok := seen[Edge[T]{id, parent}]; ok {
+					continue
+				}
+				seen[Edge[T]{id, parent}] = empty
+				eb, eo, et := edgeIn(b, id, parent), edgeIn(o, id, parent), edgeIn(t, id, parent)
+				e := eo
+				_, keptID := kept[id]
+				_, keptParent := kept[parent]
+				switch {
+				case keptID || keptParent:
+				case eb.equal(eo):
+					e = et
+				case !et.equal(eb) && !et.equal(eo):
+					conflicts = append(conflicts, Conflict[T]{
+						Kind: ConflictEdge, Role: id, Target: parent,
+						Message: fmt.Sprintf("period of %v -> %v is changed by both sides", id, parent),
+					})
+				}
+				if !e.in {
+					continue
+				}
+				if rbac.parents[id] == nil {
+					rbac.parents[id] = make(map[T]Period)
+				}
+				rbac.parents[id][parent] = e.period
+			}
+		}
+	}
+	if err := inherCircle(rbac); err != nil {
+		return nil, conflicts, err
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].String() < conflicts[j].String()
+	})
+	return rbac, conflicts, nil
+}
+
+// merge3 returns whether an item is in the merged set, given whether
+// it's in the base, ours and theirs.
+func merge3(inBase, inOurs, inTheirs bool) bool {
+	if inOurs != inBase {
+		return inOurs
+	}
+	return inTheirs
+}
+
+// modified returns true if `s` changed the grants, the metadata or the
+// parents of the role `id`, or added it as a parent, compared to `base`.
+func modified[T comparable](base, s policySnapshot[T], id T) bool {
+	if !equalMetadata(metadataOf(base.roles[id]), metadataOf(s.roles[id])) {
+		return true
+	}
+	if len(base.grants[id]) != len(s.grants[id]) {
+		return true
+	}
+	for pid := range s.grants[id] {
+		if !grantIn(base, id, pid).equal(grantIn(s, id, pid)) {
+			return true
+		}
+	}
+	if len(base.parents[id]) != len(s.parents[id]) {
+		return true
+	}
+	for parent := range s.parents[id] {
+		if !edgeIn(base, id, parent).equal(edgeIn(s, id, parent)) {
+			return true
+		}
+	}
+	for child, parents := range s.parents {
+		if _, ok := parents[id]; !ok {
+			continue
+		}
+		if _, ok := base.parents[child][id]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeGrant is a grant of a snapshot, with a nil permission if there
+// is none.
+type mergeGrant[T comparable] struct {
+	p      Permission[T]
+	period Period
+}
+
+func grantIn[T comparable](s policySnapshot[T], id, pid T) mergeGrant[T] {
+	return mergeGrant[T]{s.grants[id][pid], s.periods[id][pid]}
+}
+
+func (g mergeGrant[T]) equal(h mergeGrant[T]) bool {
+	return reflect.DeepEqual(g.p, h.p) && g.period.Equal(h.period)
+}
+
+// mergeEdge is an inheritance edge of a snapshot, or its absence.
+type mergeEdge struct {
+	in     bool
+	period Period
+}
+
+func edgeIn[T comparable](s policySnapshot[T], id, parent T) mergeEdge {
+	period, in := s.parents[id][parent]
+	return mergeEdge{in, period}
+}
+
+func (e mergeEdge) equal(f mergeEdge) bool {
+	return e.in == f.in && e.period.Equal(f.period)
+}
+
+// metadataIn returns the metadata of the role `id` in `s`, or `missing`
+// if there is no such role.
+func metadataIn[T comparable](s policySnapshot[T], id T, missing Metadata) Metadata {
+	role, ok := s.roles[id]
+	if !ok {
+		return missing
+	}
+	return metadataOf(role)
+}
+
+// equalMetadata compares metadata, taking nil tags and attributes as
+// empty ones.
+func equalMetadata(a, b Metadata) bool {
+	if a.Name != b.Name || a.Description != b.Description ||
+		len(a.Tags) != len(b.Tags) || len(a.Attributes) != len(b.Attributes) {
+		return false
+	}
+	for i := range a.Tags {
+		if a.Tags[i] != b.Tags[i] {
+			return false
+		}
+	}
+	for k, v := range a.Attributes {
+		if w, ok := b.Attributes[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
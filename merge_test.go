@@ -0,0 +1,297 @@
+package gorbac
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// prepareMerge returns a base policy and two copies of it
+func prepareMerge(t *testing.T) (base, ours, theirs *RBAC[string]) {
+	build := func() *RBAC[string] {
+		r := New[string]()
+		for _, id := range []string{"admin", "editor", "reader", "legacy"} {
+			role := NewRole(id)
+			assert(t, role.Assign(NewPermission(id+"-permission")))
+			assert(t, r.Add(role))
+		}
+		assert(t, r.SetParent("admin", "editor"))
+		assert(t, r.SetParent("editor", "reader"))
+		return r
+	}
+	return build(), build(), build()
+}
+
+func TestMerge(t *testing.T) {
+	base, ours, theirs := prepareMerge(t)
+	// ours adds a role and a grant, theirs adds an edge and removes one
+	assert(t, ours.Add(NewRole("auditor")))
+	assert(t, ours.SetParent("auditor", "reader"))
+	assert(t, roleOf(t, ours, "editor").Assign(NewPermission("publish")))
+	assert(t, theirs.SetParent("legacy", "reader"))
+	assert(t, theirs.RemoveParent("admin", "editor"))
+	assert(t, theirs.SetParent("admin", "reader"))
+	// both make the same change
+	assert(t, roleOf(t, ours, "reader").Revoke(NewPermission("reader-permission")))
+	assert(t, roleOf(t, theirs, "reader").Revoke(NewPermission("reader-permission")))
+
+	r, conflicts, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 0 {
+		t.Fatalf("No conflict expected, but %v got", conflicts)
+	}
+	parents := map[string][]string{
+		"admin":   {"reader"},
+		"editor":  {"reader"},
+		"auditor": {"reader"},
+		"legacy":  {"reader"},
+	}
+	for _, id := range []string{"admin", "editor", "reader", "legacy", "auditor"} {
+		_, got, err := r.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalIDs(got, parents[id]) {
+			t.Fatalf("%v expected as parents of [%s], but %v got", parents[id], id, got)
+		}
+	}
+	if !r.IsGranted("editor", NewPermission("publish"), nil) {
+		t.Fatal("[publish] of ours should be merged")
+	}
+	if r.IsGranted("admin", NewPermission("reader-permission"), nil) {
+		t.Fatal("[reader-permission] revoked by both sides should be revoked")
+	}
+	if r.IsGranted("admin", NewPermission("editor-permission"), nil) {
+		t.Fatal("[admin] should not inherit from [editor] any more")
+	}
+	// the inputs are kept
+	if !base.IsGranted("admin", NewPermission("reader-permission"), nil) {
+		t.Fatal("The base policy should not be changed")
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	base, ours, theirs := prepareMerge(t)
+	// ours removes a role theirs gives a new parent
+	assert(t, ours.Remove("legacy"))
+	assert(t, theirs.SetParent("legacy", "reader"))
+	// both change the same grant differently
+	assert(t, roleOf(t, ours, "reader").Assign(NewMaskPermission("comment", ActionRead)))
+	assert(t, roleOf(t, theirs, "reader").Assign(NewMaskPermission("comment", ActionRead|ActionUpdate)))
+
+	r, conflicts, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Conflict[string]{
+		{Kind: ConflictGrant, Role: "reader", Target: "comment"},
+		{Kind: ConflictRemovedRole, Role: "legacy"},
+	}
+	if len(conflicts) != len(expected) {
+		t.Fatalf("%v expected, but %v got", expected, conflicts)
+	}
+	for i, c := range conflicts {
+		if c.Kind != expected[i].Kind || c.Role != expected[i].Role || c.Target != expected[i].Target {
+			t.Fatalf("%v expected, but %v got", expected[i], c)
+		}
+	}
+	// resolved in favour of ours
+	if _, _, err := r.Get("legacy"); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatal("[legacy] removed by ours should be removed")
+	}
+	if r.IsGranted("reader", NewMaskPermission("comment", ActionUpdate), nil) {
+		t.Fatal("[comment] of ours should be kept")
+	}
+}
+
+func TestMergeKeptRole(t *testing.T) {
+	base, ours, theirs := prepareMerge(t)
+	// theirs removes a role ours changes
+	for _, r := range []*RBAC[string]{base, ours} {
+		role := roleOf(t, r, "legacy")
+		assert(t, role.Assign(NewPermission("keep")))
+		assert(t, role.Assign(NewPermission("old")))
+		assert(t, r.SetParent("legacy", "reader"))
+		assert(t, r.SetParent("admin", "legacy"))
+	}
+	assert(t, roleOf(t, ours, "legacy").Revoke(NewPermission("old")))
+	assert(t, roleOf(t, ours, "legacy").Assign(NewPermission("new")))
+	assert(t, theirs.Remove("legacy"))
+
+	r, conflicts, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Kind != ConflictRemovedRole || conflicts[0].Role != "legacy" {
+		t.Fatalf("A conflict of [legacy] expected, but %v got", conflicts)
+	}
+	for _, p := range []string{"legacy-permission", "keep", "new", "reader-permission"} {
+		if !r.IsGranted("legacy", NewPermission(p), nil) {
+			t.Fatalf("[%s] of ours should be kept", p)
+		}
+	}
+	if r.IsGranted("legacy", NewPermission("old"), nil) {
+		t.Fatal("[old] revoked by ours should be revoked")
+	}
+	_, parents, err := r.Get("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalIDs(parents, []string{"reader"}) {
+		t.Fatalf("[reader] expected as parents of [legacy], but %v got", parents)
+	}
+	_, parents, err = r.Get("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalIDs(parents, []string{"editor", "legacy"}) {
+		t.Fatalf("[editor legacy] expected as parents of [admin], but %v got", parents)
+	}
+}
+
+func TestMergePeriods(t *testing.T) {
+	expiry := Period{NotAfter: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for _, swap := range []bool{false, true} {
+		base, ours, theirs := prepareMerge(t)
+		changed := ours
+		if swap {
+			changed = theirs
+		}
+		// one side only bounds an existing grant and edge
+		assert(t, changed.AssignWithin("reader", NewPermission("reader-permission"), expiry))
+		assert(t, changed.SetParentWithin("editor", "reader", expiry))
+
+		r, conflicts, err := Merge(base, ours, theirs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(conflicts) != 0 {
+			t.Fatalf("No conflict expected, but %v got", conflicts)
+		}
+		if r.IsGranted("reader", NewPermission("reader-permission"), nil) {
+			t.Fatalf("The expired grant should be merged (swap: %v)", swap)
+		}
+		if r.IsGranted("editor", NewPermission("reader-permission"), nil) {
+			t.Fatalf("The expired edge should be merged (swap: %v)", swap)
+		}
+		if expired := r.Expired(); len(expired) != 2 {
+			t.Fatalf("2 expired entries expected, but %v got (swap: %v)", expired, swap)
+		}
+	}
+}
+
+func TestMergePeriodConflicts(t *testing.T) {
+	base, ours, theirs := prepareMerge(t)
+	before := Period{NotAfter: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	after := Period{NotAfter: time.Date(2120, 1, 1, 0, 0, 0, 0, time.UTC)}
+	assert(t, ours.AssignWithin("reader", NewPermission("reader-permission"), before))
+	assert(t, theirs.AssignWithin("reader", NewPermission("reader-permission"), after))
+	assert(t, ours.SetParentWithin("editor", "reader", before))
+	assert(t, theirs.SetParentWithin("editor", "reader", after))
+
+	r, conflicts, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Conflict[string]{
+		{Kind: ConflictEdge, Role: "editor", Target: "reader"},
+		{Kind: ConflictGrant, Role: "reader", Target: "reader-permission"},
+	}
+	if len(conflicts) != len(expected) {
+		t.Fatalf("%v expected, but %v got", expected, conflicts)
+	}
+	for i, c := range conflicts {
+		if c.Kind != expected[i].Kind || c.Role != expected[i].Role || c.Target != expected[i].Target {
+			t.Fatalf("%v expected, but %v got", expected[i], c)
+		}
+	}
+	// resolved in favour of ours
+	if r.IsGranted("editor", NewPermission("reader-permission"), nil) {
+		t.Fatal("The periods of ours should be kept")
+	}
+}
+
+func TestMergeMetadata(t *testing.T) {
+	for _, swap := range []bool{false, true} {
+		base, ours, theirs := prepareMerge(t)
+		changed := ours
+		if swap {
+			changed = theirs
+		}
+		m := Metadata{Name: "Editor", Tags: []string{"content"}, Attributes: map[string]string{"team": "web"}}
+		assert(t, changed.SetMetadata("editor", m))
+
+		r, conflicts, err := Merge(base, ours, theirs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(conflicts) != 0 {
+			t.Fatalf("No conflict expected, but %v got", conflicts)
+		}
+		got, err := r.GetMetadata("editor")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalMetadata(got, m) {
+			t.Fatalf("%v expected, but %v got (swap: %v)", m, got, swap)
+		}
+	}
+
+	base, ours, theirs := prepareMerge(t)
+	assert(t, ours.SetMetadata("editor", Metadata{Name: "Ours"}))
+	assert(t, theirs.SetMetadata("editor", Metadata{Name: "Theirs"}))
+	assert(t, theirs.SetMetadata("admin", Metadata{Name: "Admin"}))
+	r, conflicts, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Kind != ConflictMetadata || conflicts[0].Role != "editor" {
+		t.Fatalf("A conflict of [editor] expected, but %v got", conflicts)
+	}
+	for id, name := range map[string]string{"editor": "Ours", "admin": "Admin"} {
+		m, err := r.GetMetadata(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Name != name {
+			t.Fatalf("%s expected as the name of [%s], but %s got", name, id, m.Name)
+		}
+	}
+}
+
+func TestMergeCircle(t *testing.T) {
+	base, ours, theirs := prepareMerge(t)
+	assert(t, ours.SetParent("reader", "legacy"))
+	assert(t, theirs.SetParent("legacy", "admin"))
+	r, _, err := Merge(base, ours, theirs)
+	var cycle *CycleError[string]
+	if !errors.As(err, &cycle) || r != nil {
+		t.Fatalf("A *CycleError expected, but %v got", err)
+	}
+}
+
+func roleOf(t *testing.T, r *RBAC[string], id string) Role[string] {
+	role, _, err := r.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return role
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool)
+	for _, id := range a {
+		set[id] = true
+	}
+	for _, id := range b {
+		if !set[id] {
+			return false
+		}
+	}
+	return true
+}
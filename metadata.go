@@ -0,0 +1,99 @@
+package gorbac
+
+import "errors"
+
+var (
+	// ErrMetadataNotSupported occurred if a role doesn't implement
+	// MetadataRole
+	ErrMetadataNotSupported = errors.New("Role does not support metadata")
+)
+
+// Metadata describes a role for humans and external systems.
+type Metadata struct {
+	// Name is the display name
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+}
+
+// HasTag returns true if the metadata is tagged with `tag`.
+func (m Metadata) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of the metadata not sharing its tags and
+// attributes.
+func (m Metadata) clone() Metadata {
+	if m.Tags != nil {
+		m.Tags = append([]string(nil), m.Tags...)
+	}
+	if m.Attributes != nil {
+		attributes := make(map[string]string, len(m.Attributes))
+		for k, v := range m.Attributes {
+			attributes[k] = v
+		}
+		m.Attributes = attributes
+	}
+	return m
+}
+
+// IsZero returns true if the metadata is empty.
+func (m Metadata) IsZero() bool {
+	return m.Name == "" && m.Description == "" &&
+		len(m.Tags) == 0 && len(m.Attributes) == 0
+}
+
+// SetMetadata replaces the metadata of the role `id` by a copy of `m`.
+// The role must implement MetadataRole.
+func (rbac *RBAC[T]) SetMetadata(id T, m Metadata) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	role, ok := rbac.roles[id]
+	if !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	mr, ok := role.(MetadataRole)
+	if !ok {
+		return ErrMetadataNotSupported
+	}
+	mr.SetMetadata(m.clone())
+	return nil
+}
+
+// GetMetadata returns a copy of the metadata of the role `id`.
+// Roles not implementing MetadataRole have empty metadata.
+func (rbac *RBAC[T]) GetMetadata(id T) (Metadata, error) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	role, ok := rbac.roles[id]
+	if !ok {
+		return Metadata{}, &RoleNotFoundError[T]{id}
+	}
+	return metadataOf(role).clone(), nil
+}
+
+func metadataOf[T comparable](role Role[T]) Metadata {
+	if mr, ok := role.(MetadataRole); ok {
+		return mr.GetMetadata()
+	}
+	return Metadata{}
+}
+
+// FindByTag returns the roles tagged with `tag`.
+func (rbac *RBAC[T]) FindByTag(tag string) []T {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	var result []T
+	for id, role := range rbac.roles {
+		if metadataOf(role).HasTag(tag) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	r := New[string]()
+	editor := NewRole("editor")
+	editor.Metadata = Metadata{
+		Name:        "Editor",
+		Description: "Edits articles",
+		Tags:        []string{"content"},
+	}
+	assert(t, r.Add(editor))
+	assert(t, r.Add(NewRole("viewer")))
+	if err := r.SetMetadata("not-exist", Metadata{}); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s needed", ErrRoleNotExist)
+	}
+	assert(t, r.SetMetadata("viewer", Metadata{
+		Name:       "Viewer",
+		Tags:       []string{"content", "readonly"},
+		Attributes: map[string]string{"team": "newsroom"},
+	}))
+
+	role, _, err := r.Get("editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role != editor || editor.Metadata.Name != "Editor" {
+		t.Fatalf("Unexpected role: %v", role)
+	}
+	if m, err := r.GetMetadata("editor"); err != nil || m.Description != "Edits articles" {
+		t.Fatalf("Unexpected metadata: %v, %v", m, err)
+	}
+	assert(t, Walk(r, func(role Role[string], _ []string) error {
+		m := role.(MetadataRole).GetMetadata()
+		if role.ID() == "viewer" && m.Attributes["team"] != "newsroom" {
+			t.Fatalf("Unexpected metadata: %v", m)
+		}
+		return nil
+	}))
+	if ids := r.FindByTag("content"); len(ids) != 2 {
+		t.Fatalf("Two roles should be tagged, but %v got", ids)
+	}
+	if ids := r.FindByTag("readonly"); len(ids) != 1 || ids[0] != "viewer" {
+		t.Fatalf("[viewer] should be tagged, but %v got", ids)
+	}
+
+	text, err := json.Marshal(role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(text), `"description":"Edits articles"`) {
+		t.Fatalf("Metadata should be serialised, but %s got", text)
+	}
+	text, err = json.Marshal(NewRole("plain"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != `{"id":"plain"}` {
+		t.Fatalf("Empty metadata should be omitted, but %s got", text)
+	}
+}
+
+type bareRole struct {
+	Role[string]
+}
+
+func TestMetadataNotSupported(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(bareRole{NewRole("bare")}))
+	if err := r.SetMetadata("bare", Metadata{Name: "Bare"}); err != ErrMetadataNotSupported {
+		t.Fatalf("%s needed", ErrMetadataNotSupported)
+	}
+	if m, err := r.GetMetadata("bare"); err != nil || !m.IsZero() {
+		t.Fatalf("Empty metadata expected, but %v, %v got", m, err)
+	}
+}
+
+func TestMetadataCopied(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(NewRole("viewer")))
+	m := Metadata{
+		Tags:       []string{"readonly"},
+		Attributes: map[string]string{"team": "newsroom"},
+	}
+	assert(t, r.SetMetadata("viewer", m))
+	m.Tags[0] = "content"
+	m.Attributes["team"] = "sports"
+
+	got, err := r.GetMetadata("viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Tags[0] != "readonly" || got.Attributes["team"] != "newsroom" {
+		t.Fatalf("Metadata should be stored by copy, but %v got", got)
+	}
+	got.Tags[0] = "content"
+	got.Attributes["team"] = "sports"
+	role, _, _ := r.Get("viewer")
+	got = role.(MetadataRole).GetMetadata()
+	got.Tags[0] = "content"
+	if ids := r.FindByTag("content"); len(ids) != 0 {
+		t.Fatalf("Metadata should be returned by copy, but %v got", ids)
+	}
+	if m, _ := r.GetMetadata("viewer"); m.Attributes["team"] != "newsroom" {
+		t.Fatalf("Metadata should be returned by copy, but %v got", m)
+	}
+}
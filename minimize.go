@@ -0,0 +1,160 @@
+package gorbac
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Kinds of edits made by Minimize
+const (
+	// EditRemoveParent removes an inheritance edge
+	EditRemoveParent = "remove-parent"
+	// EditRemoveGrant revokes a permission from a role
+	EditRemoveGrant = "remove-grant"
+)
+
+// Edit is a change to a policy.
+type Edit[T comparable] struct {
+	// Kind is either EditRemoveParent or EditRemoveGrant
+	Kind string `json:"kind"`
+	Role T      `json:"role"`
+	// Target is the parent or the permission ID
+	Target T `json:"target"`
+}
+
+func (e Edit[T]) String() string {
+	return fmt.Sprintf("%s %v %v", e.Kind, e.Role, e.Target)
+}
+
+// Minimize computes the transitive reduction of the inheritance graph
+// and revokes permissions already covered by an ancestor.
+// The edits are applied only if `apply` is true, otherwise it's a dry
+// run. Either way IsGranted answers the same for every role and
+// permission before and after the edits.
+//
+// Time-bounded edges and grants are kept, and only untimed ones count
+// as covering others. A policy with circle inheritance is refused with
+// a *CycleError.
+func Minimize[T comparable](rbac *RBAC[T], apply bool) ([]Edit[T], error) {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if err := inherCircle(rbac); err != nil {
+		return nil, err
+	}
+	var edits []Edit[T]
+	for id, parents := range rbac.parents {
+		for parent, period := range parents {
+			if !period.IsZero() {
+				continue
+			}
+			for via, viaPeriod := range parents {
+				if via == parent || !viaPeriod.IsZero() {
+					continue
+				}
+				if _, ok := rbac.untimedAncestors(via)[parent]; ok {
+					edits = append(edits, Edit[T]{EditRemoveParent, id, parent})
+					break
+				}
+			}
+		}
+	}
+	for id, role := range rbac.roles {
+		ancestors := rbac.untimedAncestors(id)
+		delete(ancestors, id)
+		periods := grantPeriods(role)
+		for _, p := range role.Permissions() {
+			if _, ok := periods[p.ID()]; ok {
+				continue
+			}
+			if rbac.covered(p, ancestors) {
+				edits = append(edits, Edit[T]{EditRemoveGrant, id, p.ID()})
+			}
+		}
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].String() < edits[j].String()
+	})
+	if apply {
+		for _, e := range edits {
+			switch e.Kind {
+			case EditRemoveParent:
+				delete(rbac.parents[e.Role], e.Target)
+			case EditRemoveGrant:
+				role := rbac.roles[e.Role]
+				for _, p := range role.Permissions() {
+					if p.ID() == e.Target {
+						role.Revoke(p)
+					}
+				}
+			}
+		}
+	}
+	return edits, nil
+}
+
+// untimedAncestors returns `id` and every role it inherits from through
+// edges without periods.
+func (rbac *RBAC[T]) untimedAncestors(id T) map[T]struct{} {
+	result := make(map[T]struct{})
+	stack := []T{id}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := result[id]; ok {
+			continue
+		}
+		result[id] = empty
+		for parent, period := range rbac.parents[id] {
+			if period.IsZero() {
+				stack = append(stack, parent)
+			}
+		}
+	}
+	return result
+}
+
+// covered returns true if an untimed grant of any of `ancestors`
+// matches everything `p` matches.
+func (rbac *RBAC[T]) covered(p Permission[T], ancestors map[T]struct{}) bool {
+	for aid := range ancestors {
+		role, ok := rbac.roles[aid]
+		if !ok {
+			continue
+		}
+		periods := grantPeriods(role)
+		for _, q := range role.Permissions() {
+			if _, ok := periods[q.ID()]; ok {
+				continue
+			}
+			if covers(q, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// covers returns true if the grant `q` matches everything the grant `p`
+// matches. Besides identical permissions, it's decided only for the
+// built-in permission types whose Match is transitive. A LayerPermission
+// also matches any permission of the same ID, so only an identical one
+// covers it.
+func covers[T comparable](q, p Permission[T]) bool {
+	if reflect.DeepEqual(q, p) {
+		return true
+	}
+	switch any(p).(type) {
+	case StdPermission[T]:
+		// p matches permissions with the same ID only
+		switch any(q).(type) {
+		case StdPermission[T], LayerPermission, ResourcePermission, RegexPermission:
+			return q.Match(p)
+		}
+	case ResourcePermission, MaskPermission:
+		if reflect.TypeOf(p) == reflect.TypeOf(q) {
+			return q.Match(p)
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// grantedPairs returns IsGranted of every role and `permissions`.
+func grantedPairs(r *RBAC[string], permissions []Permission[string]) map[string]bool {
+	var ids []string
+	Walk(r, func(role Role[string], _ []string) error {
+		ids = append(ids, role.ID())
+		return nil
+	})
+	result := make(map[string]bool)
+	for _, id := range ids {
+		for _, p := range permissions {
+			result[fmt.Sprintf("%s %T %s", id, p, p.ID())] = r.IsGranted(id, p, nil)
+		}
+	}
+	return result
+}
+
+func TestMinimize(t *testing.T) {
+	r := New[string]()
+	for _, id := range []string{"admin", "editor", "author", "reader", "guest"} {
+		assert(t, r.Add(NewRole(id)))
+	}
+	permissions := []Permission[string]{
+		NewPermission("read"),
+		NewPermission("write"),
+		NewLayerPermission("articles", "/"),
+		NewLayerPermission("articles/edit", "/"),
+		NewResourcePermission("document", ResourceAny, "edit"),
+		NewResourcePermission("document", "42", "edit"),
+		NewMaskPermission("comment", ActionRead|ActionUpdate),
+		NewMaskPermission("comment", ActionRead),
+	}
+	grant := func(id string, i int) {
+		assert(t, r.AssignWithin(id, permissions[i], Period{}))
+	}
+	grant("reader", 0)
+	grant("reader", 2)
+	grant("reader", 4)
+	grant("reader", 6)
+	grant("author", 0)
+	grant("author", 3)
+	grant("author", 5)
+	grant("author", 7)
+	grant("editor", 1)
+	grant("admin", 1)
+	grant("guest", 0)
+	assert(t, r.SetParent("admin", "editor"))
+	assert(t, r.SetParent("editor", "author"))
+	assert(t, r.SetParent("author", "reader"))
+	assert(t, r.SetParents("admin", []string{"author", "reader"}))
+	// a time-bounded edge neither is removed nor covers others
+	assert(t, r.SetParentWithin("guest", "reader", Period{NotAfter: r.clock().Add(1e12)}))
+
+	requests := append([]Permission[string]{
+		NewLayerPermission("articles/edit/1", "/"),
+		NewPermission("articles/edit"),
+		NewResourcePermission("document", "42", "edit"),
+		NewResourcePermission("document", "7", "edit"),
+	}, permissions...)
+	before := grantedPairs(r, requests)
+
+	edits, err := Minimize(r, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Edit[string]{
+		{EditRemoveGrant, "admin", "write"},
+		{EditRemoveGrant, "author", "comment"},
+		{EditRemoveGrant, "author", "document:42:edit"},
+		{EditRemoveGrant, "author", "read"},
+		{EditRemoveParent, "admin", "author"},
+		{EditRemoveParent, "admin", "reader"},
+	}
+	if len(edits) != len(expected) {
+		t.Fatalf("%v expected, but %v got", expected, edits)
+	}
+	for i := range edits {
+		if edits[i] != expected[i] {
+			t.Fatalf("%v expected, but %v got", expected, edits)
+		}
+	}
+	if parents, _ := r.GetParents("admin"); len(parents) != 3 {
+		t.Fatal("A dry run should not change the policy")
+	}
+
+	if _, err := Minimize(r, true); err != nil {
+		t.Fatal(err)
+	}
+	if parents, _ := r.GetParents("admin"); len(parents) != 1 {
+		t.Fatalf("[admin] should only inherit from [editor], but %v got", parents)
+	}
+	after := grantedPairs(r, requests)
+	for pair, granted := range before {
+		if after[pair] != granted {
+			t.Fatalf("IsGranted of `%s` changed from %v", pair, granted)
+		}
+	}
+	if edits, _ := Minimize(r, false); len(edits) != 0 {
+		t.Fatalf("A minimized policy should not be changed again, but %v got", edits)
+	}
+}
+
+func TestMinimizeCircle(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(NewRole("role-x")))
+	assert(t, r.Add(NewRole("role-y")))
+	assert(t, r.SetParent("role-x", "role-y"))
+	assert(t, r.SetParent("role-y", "role-x"))
+	if _, err := Minimize(r, true); !errors.Is(err, ErrFoundCircle) {
+		t.Fatalf("%s needed, but %v got", ErrFoundCircle, err)
+	}
+}
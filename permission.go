@@ -7,6 +7,18 @@ type Permission[T comparable] interface {
 	Match(Permission[T]) bool
 }
 
+// MergeablePermission is implemented by permissions which are combined
+// with the permission of the same ID already assigned to a role,
+// instead of replacing it.
+type MergeablePermission[T comparable] interface {
+	Permission[T]
+	// Merge returns the union of the permission and `p`
+	Merge(p Permission[T]) Permission[T]
+	// Subtract returns what remains after removing `p`,
+	// or nil if nothing remains
+	Subtract(p Permission[T]) Permission[T]
+}
+
 // Permissions list
 type Permissions[T comparable] map[T]Permission[T]
 
@@ -0,0 +1,64 @@
+package gorbac
+
+import (
+	"errors"
+)
+
+var (
+	// ErrCompositeAssign occurred if a composite permission is assigned
+	// to a role, composites are meant for checking only
+	ErrCompositeAssign = errors.New("Composite permission cann't be assigned")
+)
+
+// AllOf returns a permission requiring every one of `permissions`.
+func AllOf[T comparable](permissions ...Permission[T]) CompositePermission[T] {
+	return CompositePermission[T]{All: true, Permissions: permissions}
+}
+
+// AnyOf returns a permission requiring any one of `permissions`.
+func AnyOf[T comparable](permissions ...Permission[T]) CompositePermission[T] {
+	return CompositePermission[T]{Permissions: permissions}
+}
+
+// CompositePermission combines permissions with AND/OR semantics.
+// RBAC.IsGranted evaluates each of them through the whole inheritance
+// chain, so one can be granted by a role and another by its parent.
+// An empty composite is never granted.
+type CompositePermission[T comparable] struct {
+	// All requires every permission if true, any of them otherwise
+	All         bool            `json:"all"`
+	Permissions []Permission[T] `json:"permissions"`
+}
+
+// ID returns the zero value, a composite has no ID of its own
+func (p CompositePermission[T]) ID() T {
+	var zero T
+	return zero
+}
+
+// Match another permission by the combined permissions
+func (p CompositePermission[T]) Match(a Permission[T]) bool {
+	return p.Evaluate(func(q Permission[T]) bool {
+		return q.Match(a)
+	})
+}
+
+// Evaluate combines the results of `check` on every permission.
+// Nested composites are evaluated recursively.
+func (p CompositePermission[T]) Evaluate(check func(Permission[T]) bool) bool {
+	if len(p.Permissions) == 0 {
+		return false
+	}
+	for _, q := range p.Permissions {
+		var ok bool
+		if c, isComposite := q.(CompositePermission[T]); isComposite {
+			ok = c.Evaluate(check)
+		} else {
+			ok = check(q)
+		}
+		if ok != p.All {
+			return ok
+		}
+	}
+	return p.All
+}
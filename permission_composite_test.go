@@ -0,0 +1,46 @@
+package gorbac
+
+import (
+	"testing"
+)
+
+func TestCompositePermission(t *testing.T) {
+	read, download, printing := NewPermission("read"), NewPermission("download"), NewPermission("print")
+	r := New[string]()
+	viewer, exporter := NewRole("viewer"), NewRole("exporter")
+	assert(t, viewer.Assign(read))
+	assert(t, exporter.Assign(download))
+	assert(t, r.Add(viewer))
+	assert(t, r.Add(exporter))
+	assert(t, r.SetParent("exporter", "viewer"))
+
+	export := AllOf[string](read, download)
+	if !r.IsGranted("exporter", export, nil) {
+		t.Fatal("[exporter] should satisfy `read` through its parent and `download` directly")
+	}
+	if exporter.Permit(export) {
+		t.Fatal("[exporter] itself should not satisfy `read`")
+	}
+	if r.IsGranted("viewer", export, nil) {
+		t.Fatal("[viewer] should not satisfy `download`")
+	}
+	if !r.IsGranted("viewer", AnyOf[string](printing, read), nil) {
+		t.Fatal("[viewer] should satisfy `read`")
+	}
+	if r.IsGranted("viewer", AnyOf[string](printing, download), nil) {
+		t.Fatal("[viewer] should satisfy neither `print` nor `download`")
+	}
+	nested := AllOf[string](read, AnyOf[string](printing, download))
+	if !r.IsGranted("exporter", nested, nil) || r.IsGranted("viewer", nested, nil) {
+		t.Fatal("Nested composites should be evaluated recursively")
+	}
+	if r.IsGranted("exporter", AllOf[string](), nil) || r.IsGranted("exporter", AnyOf[string](), nil) {
+		t.Fatal("Empty composites should never be granted")
+	}
+	if !AnyGranted(r, []string{"viewer", "exporter"}, export, nil) {
+		t.Fatal("Composites should work with AnyGranted")
+	}
+	if err := viewer.Assign(export); err != ErrCompositeAssign {
+		t.Fatalf("%s needed", ErrCompositeAssign)
+	}
+}
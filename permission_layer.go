@@ -43,3 +43,51 @@ func (p LayerPermission) Match(parent Permission[string]) bool {
 	}
 	return true
 }
+
+// layerTrie indexes layered IDs split by one separator.
+type layerTrie struct {
+	children map[string]*layerTrie
+	terminal bool
+}
+
+func newLayerTrie() *layerTrie {
+	return &layerTrie{children: make(map[string]*layerTrie)}
+}
+
+func (t *layerTrie) insert(layers []string) {
+	for _, layer := range layers {
+		child, ok := t.children[layer]
+		if !ok {
+			child = newLayerTrie()
+			t.children[layer] = child
+		}
+		t = child
+	}
+	t.terminal = true
+}
+
+func (t *layerTrie) remove(layers []string) {
+	for _, layer := range layers {
+		child, ok := t.children[layer]
+		if !ok {
+			return
+		}
+		t = child
+	}
+	t.terminal = false
+}
+
+// match returns true if any inserted ID is a prefix of `layers`.
+func (t *layerTrie) match(layers []string) bool {
+	for _, layer := range layers {
+		child, ok := t.children[layer]
+		if !ok {
+			return false
+		}
+		if child.terminal {
+			return true
+		}
+		t = child
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnknownAction occurred if an action name isn't registered
+	ErrUnknownAction = errors.New("Unknown action")
+	// ErrTooManyActions occurred if more than 64 actions are registered
+	ErrTooManyActions = errors.New("Too many actions")
+)
+
+// Action is a bitset of named actions.
+type Action uint64
+
+// Predefined CRUD actions
+const (
+	ActionCreate Action = 1 << iota
+	ActionRead
+	ActionUpdate
+	ActionDelete
+)
+
+var actions = struct {
+	sync.RWMutex
+	names []string
+}{names: []string{"create", "read", "update", "delete"}}
+
+// RegisterAction returns the action named `name`, registering it when
+// it is unknown. At most 64 actions can be registered.
+func RegisterAction(name string) (Action, error) {
+	actions.Lock()
+	defer actions.Unlock()
+	for i, n := range actions.names {
+		if n == name {
+			return 1 << i, nil
+		}
+	}
+	if len(actions.names) == 64 {
+		return 0, ErrTooManyActions
+	}
+	actions.names = append(actions.names, name)
+	return 1 << (len(actions.names) - 1), nil
+}
+
+// ParseAction parses registered action names separated by "|",
+// e.g. "read|update".
+func ParseAction(s string) (Action, error) {
+	actions.RLock()
+	defer actions.RUnlock()
+	var a Action
+	if s == "" {
+		return a, nil
+	}
+	for _, name := range strings.Split(s, "|") {
+		i := indexOf(actions.names, strings.TrimSpace(name))
+		if i < 0 {
+			return 0, ErrUnknownAction
+		}
+		a |= 1 << i
+	}
+	return a, nil
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// String returns the action names separated by "|".
+// Unregistered actions are left out.
+func (a Action) String() string {
+	names, _ := a.names()
+	return strings.Join(names, "|")
+}
+
+// names returns the names of the registered actions and the bits of the
+// unregistered ones.
+func (a Action) names() (names []string, unknown Action) {
+	actions.RLock()
+	defer actions.RUnlock()
+	for a != 0 {
+		i := bits.TrailingZeros64(uint64(a))
+		if i < len(actions.names) {
+			names = append(names, actions.names[i])
+		} else {
+			unknown |= 1 << i
+		}
+		a &^= 1 << i
+	}
+	return
+}
+
+// MarshalText encodes the action by its names. An ErrUnknownAction is
+// returned if any action isn't registered, as it couldn't be decoded.
+func (a Action) MarshalText() ([]byte, error) {
+	names, unknown := a.names()
+	if unknown != 0 {
+		return nil, fmt.Errorf("%w: %#x", ErrUnknownAction, uint64(unknown))
+	}
+	return []byte(strings.Join(names, "|")), nil
+}
+
+// UnmarshalText decodes the action from its names
+func (a *Action) UnmarshalText(text []byte) (err error) {
+	*a, err = ParseAction(string(text))
+	return
+}
+
+// NewMaskPermission returns an instance of permission allowing
+// `actions` on the `resource`.
+func NewMaskPermission(resource string, actions Action) MaskPermission {
+	return MaskPermission{resource, actions}
+}
+
+// MaskPermission allows a set of actions on a resource.
+// Its ID is the resource, assigning it to a role which already has a
+// MaskPermission of the same resource merges their actions.
+type MaskPermission struct {
+	SID     string `json:"id"`
+	Actions Action `json:"actions"`
+}
+
+// ID returns id
+func (p MaskPermission) ID() string {
+	return p.SID
+}
+
+// Match another permission requesting a subset of the actions
+func (p MaskPermission) Match(a Permission[string]) bool {
+	q, ok := a.(MaskPermission)
+	if !ok {
+		return false
+	}
+	return p.SID == q.SID && q.Actions != 0 && q.Actions&^p.Actions == 0
+}
+
+// Merge the actions of another permission of the same resource
+func (p MaskPermission) Merge(a Permission[string]) Permission[string] {
+	q, ok := a.(MaskPermission)
+	if !ok || q.SID != p.SID {
+		return a
+	}
+	return MaskPermission{p.SID, p.Actions | q.Actions}
+}
+
+// Subtract the actions of another permission of the same resource
+func (p MaskPermission) Subtract(a Permission[string]) Permission[string] {
+	q, ok := a.(MaskPermission)
+	if !ok || q.SID != p.SID {
+		return nil
+	}
+	if rest := p.Actions &^ q.Actions; rest != 0 {
+		return MaskPermission{p.SID, rest}
+	}
+	return nil
+}
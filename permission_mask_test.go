@@ -0,0 +1,106 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestAction(t *testing.T) {
+	approve, err := RegisterAction("approve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := RegisterAction("approve"); again != approve {
+		t.Fatal("Registering an action twice should return the same action")
+	}
+	a, err := ParseAction("read | approve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != ActionRead|approve {
+		t.Fatalf("`read|approve` expected, but `%s` got", a)
+	}
+	if a.String() != "read|approve" {
+		t.Fatalf("`read|approve` expected, but `%s` got", a)
+	}
+	if _, err := ParseAction("read|fly"); err != ErrUnknownAction {
+		t.Fatalf("%s needed", ErrUnknownAction)
+	}
+}
+
+func TestMaskPermission(t *testing.T) {
+	rw := NewMaskPermission("article", ActionRead|ActionUpdate)
+	if !rw.Match(NewMaskPermission("article", ActionRead)) {
+		t.Fatal("`read|update` should include `read`")
+	}
+	if rw.Match(NewMaskPermission("article", ActionRead|ActionDelete)) {
+		t.Fatal("`read|update` should not include `read|delete`")
+	}
+	if rw.Match(NewMaskPermission("comment", ActionRead)) {
+		t.Fatal("Resources should be different")
+	}
+	if rw.Match(NewMaskPermission("article", 0)) || rw.Match(NewPermission("article")) {
+		t.Fatal("Empty masks and other permission types should not be matched")
+	}
+
+	text, err := json.Marshal(rw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != `{"id":"article","actions":"read|update"}` {
+		t.Fatalf("Unexpected JSON: %s", text)
+	}
+	var p MaskPermission
+	if err := json.Unmarshal(text, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p != rw {
+		t.Fatalf("%v expected, but %v got", rw, p)
+	}
+}
+
+func TestMaskPermissionAssign(t *testing.T) {
+	role := NewRole("editor")
+	assert(t, role.Assign(NewMaskPermission("article", ActionRead)))
+	assert(t, role.Assign(NewMaskPermission("article", ActionUpdate|ActionDelete)))
+	if n := len(role.Permissions()); n != 1 {
+		t.Fatalf("Masks should be merged into one permission, but %d got", n)
+	}
+	if !role.Permit(NewMaskPermission("article", ActionRead|ActionDelete)) {
+		t.Fatal("Merged actions should be permitted")
+	}
+	assert(t, role.Revoke(NewMaskPermission("article", ActionDelete)))
+	if role.Permit(NewMaskPermission("article", ActionDelete)) {
+		t.Fatal("Revoked actions should not be permitted")
+	}
+	if !role.Permit(NewMaskPermission("article", ActionRead|ActionUpdate)) {
+		t.Fatal("Remaining actions should be permitted")
+	}
+	assert(t, role.Revoke(NewMaskPermission("article", ActionRead|ActionUpdate)))
+	if n := len(role.Permissions()); n != 0 {
+		t.Fatalf("No permission should remain, but %d got", n)
+	}
+}
+
+func TestMaskPermissionUnknownAction(t *testing.T) {
+	p := NewMaskPermission("doc", ActionRead|Action(1<<63))
+	if _, err := p.Actions.MarshalText(); !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("%s expected, but %v got", ErrUnknownAction, err)
+	}
+	// serialisers fail instead of losing the grant
+	if _, err := json.Marshal(p); !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("%s expected by JSON, but %v got", ErrUnknownAction, err)
+	}
+	r := New[string]()
+	role := NewRole("reader")
+	assert(t, role.Assign(p))
+	assert(t, r.Add(role))
+	if err := FormatDSL(io.Discard, ExportPolicy(r), StringCodec{}); !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("%s expected by DSL, but %v got", ErrUnknownAction, err)
+	}
+	if err := ExportCSV(r, StringCodec{}, io.Discard, io.Discard, io.Discard); !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("%s expected by CSV, but %v got", ErrUnknownAction, err)
+	}
+}
@@ -0,0 +1,74 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+)
+
+// MaxRegexLength is the maximum length of a RegexPermission pattern
+const MaxRegexLength = 1024
+
+var (
+	// ErrRegexTooLong occurred if a pattern exceeds MaxRegexLength
+	ErrRegexTooLong = errors.New("Regular expression is too long")
+)
+
+// NewRegexPermission returns an instance of permission matching IDs by
+// the regular expression `pattern`. It's compiled by the RE2 engine of
+// package regexp, which runs in linear time of the input.
+func NewRegexPermission(pattern string) (RegexPermission, error) {
+	if len(pattern) > MaxRegexLength {
+		return RegexPermission{}, ErrRegexTooLong
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return RegexPermission{}, err
+	}
+	return RegexPermission{re}, nil
+}
+
+// RegexPermission matches permission IDs by a regular expression,
+// e.g. `^report-(daily|weekly)-.*$`. Its ID is the pattern.
+type RegexPermission struct {
+	re *regexp.Regexp
+}
+
+// ID returns the pattern
+func (p RegexPermission) ID() string {
+	if p.re == nil {
+		return ""
+	}
+	return p.re.String()
+}
+
+// Match another permission by its ID
+func (p RegexPermission) Match(a Permission[string]) bool {
+	if p.re == nil {
+		return false
+	}
+	return p.re.String() == a.ID() || p.re.MatchString(a.ID())
+}
+
+type regexJSON struct {
+	Pattern string `json:"pattern"`
+}
+
+// MarshalJSON encodes the permission with its pattern
+func (p RegexPermission) MarshalJSON() ([]byte, error) {
+	return json.Marshal(regexJSON{p.ID()})
+}
+
+// UnmarshalJSON decodes and compiles the pattern
+func (p *RegexPermission) UnmarshalJSON(data []byte) error {
+	var v regexJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	q, err := NewRegexPermission(v.Pattern)
+	if err != nil {
+		return err
+	}
+	*p = q
+	return nil
+}
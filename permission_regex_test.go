@@ -0,0 +1,79 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRegexPermission(t *testing.T) {
+	reports, err := NewRegexPermission(`^report-(daily|weekly)-.*$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reports.Match(NewPermission("report-daily-sales")) {
+		t.Fatalf("`%s` should have the permission `report-daily-sales`", reports.ID())
+	}
+	if reports.Match(NewPermission("report-monthly-sales")) {
+		t.Fatalf("`%s` should not have the permission `report-monthly-sales`", reports.ID())
+	}
+	if !reports.Match(reports) {
+		t.Fatalf("`%[1]s` should have the permission `%[1]s`", reports.ID())
+	}
+	var zero RegexPermission
+	if zero.Match(NewPermission("")) {
+		t.Fatal("A zero permission should not match anything")
+	}
+
+	text, err := json.Marshal(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != `{"pattern":"^report-(daily|weekly)-.*$"}` {
+		t.Fatalf("Unexpected JSON: %s", text)
+	}
+	var p RegexPermission
+	if err := json.Unmarshal(text, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID() != reports.ID() || !p.Match(NewPermission("report-weekly-hr")) {
+		t.Fatalf("`%s` expected, but `%s` got", reports.ID(), p.ID())
+	}
+	if err := json.Unmarshal([]byte(`{"pattern":"("}`), &p); err == nil {
+		t.Fatal("An invalid pattern should not be decoded")
+	}
+}
+
+func TestRegexPermissionLimit(t *testing.T) {
+	if _, err := NewRegexPermission(strings.Repeat("a", MaxRegexLength+1)); err != ErrRegexTooLong {
+		t.Fatalf("%s needed", ErrRegexTooLong)
+	}
+	if _, err := NewRegexPermission(`(a`); err == nil {
+		t.Fatal("An invalid pattern should not be compiled")
+	}
+	// RE2 doesn't backtrack, pathological patterns run in linear time
+	p, err := NewRegexPermission(`^(a+)+$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Match(NewPermission(strings.Repeat("a", 4096) + "b")) {
+		t.Fatal("The pattern should not match")
+	}
+}
+
+func TestRegexPermissionGranted(t *testing.T) {
+	r := New[string]()
+	analyst := NewRole("analyst")
+	reports, err := NewRegexPermission(`^report-(daily|weekly)-.*$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, analyst.Assign(reports))
+	assert(t, r.Add(analyst))
+	if !r.IsGranted("analyst", NewPermission("report-daily-sales"), nil) {
+		t.Fatal("[analyst] should read daily reports")
+	}
+	if r.IsGranted("analyst", NewPermission("report-yearly-sales"), nil) {
+		t.Fatal("[analyst] should not read yearly reports")
+	}
+}
@@ -0,0 +1,68 @@
+package gorbac
+
+import (
+	"errors"
+	"strings"
+)
+
+// ResourceAny matches any resource type, resource ID or action
+const ResourceAny = "*"
+
+var (
+	// ErrInvalidResourcePermission occurred if an ID can't be parsed
+	// as `type:resource:action`
+	ErrInvalidResourcePermission = errors.New("Invalid resource permission")
+)
+
+// NewResourcePermission returns an instance of permission allowing
+// `action` on the resource `id` of type `typ`.
+// ResourceAny can be used as any of them.
+func NewResourcePermission(typ, id, action string) ResourcePermission {
+	return ResourcePermission{typ, id, action}
+}
+
+// ParseResourcePermission parses an ID formatted as
+// `type:resource:action`. The resource may contain ":".
+func ParseResourcePermission(id string) (ResourcePermission, error) {
+	first := strings.Index(id, ":")
+	last := strings.LastIndex(id, ":")
+	if first < 0 || first == last {
+		return ResourcePermission{}, ErrInvalidResourcePermission
+	}
+	return ResourcePermission{id[:first], id[first+1 : last], id[last+1:]}, nil
+}
+
+// ResourcePermission allows an action on a specific object.
+// Its ID is formatted as `type:resource:action`.
+type ResourcePermission struct {
+	Type     string `json:"type"`
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+}
+
+// ID returns id
+func (p ResourcePermission) ID() string {
+	return p.Type + ":" + p.Resource + ":" + p.Action
+}
+
+// Match another permission.
+// Other permission types are matched by parsing their IDs.
+func (p ResourcePermission) Match(a Permission[string]) bool {
+	if p.ID() == a.ID() {
+		return true
+	}
+	q, ok := a.(ResourcePermission)
+	if !ok {
+		var err error
+		if q, err = ParseResourcePermission(a.ID()); err != nil {
+			return false
+		}
+	}
+	return matchResource(p.Type, q.Type) &&
+		matchResource(p.Resource, q.Resource) &&
+		matchResource(p.Action, q.Action)
+}
+
+func matchResource(granted, requested string) bool {
+	return granted == ResourceAny || granted == requested
+}
@@ -0,0 +1,79 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResourcePermission(t *testing.T) {
+	anyDoc := NewResourcePermission("document", ResourceAny, "edit")
+	doc42 := NewResourcePermission("document", "42", "edit")
+	read42 := NewResourcePermission("document", "42", "read")
+	if anyDoc.ID() != "document:*:edit" {
+		t.Fatalf("`document:*:edit` expected, but `%s` got", anyDoc.ID())
+	}
+	if !anyDoc.Match(doc42) {
+		t.Fatalf("`%s` should have the permission `%s`", anyDoc.ID(), doc42.ID())
+	}
+	if doc42.Match(anyDoc) {
+		t.Fatalf("`%s` should not have the permission `%s`", doc42.ID(), anyDoc.ID())
+	}
+	if anyDoc.Match(read42) {
+		t.Fatalf("`%s` should not have the permission `%s`", anyDoc.ID(), read42.ID())
+	}
+	if !anyDoc.Match(NewPermission("document:7:edit")) {
+		t.Fatal("Standard permissions should be matched by their IDs")
+	}
+	if anyDoc.Match(NewPermission("document")) {
+		t.Fatal("Malformed IDs should not be matched")
+	}
+
+	text, err := json.Marshal(anyDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p ResourcePermission
+	if err := json.Unmarshal(text, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p != anyDoc {
+		t.Fatalf("`%s` expected, but `%s` got", anyDoc.ID(), p.ID())
+	}
+}
+
+func TestParseResourcePermission(t *testing.T) {
+	p, err := ParseResourcePermission("url:https://example.com:get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != "url" || p.Resource != "https://example.com" || p.Action != "get" {
+		t.Fatalf("Unexpected permission: %#v", p)
+	}
+	for _, id := range []string{"document", "document:edit"} {
+		if _, err := ParseResourcePermission(id); err != ErrInvalidResourcePermission {
+			t.Fatalf("%s needed for `%s`", ErrInvalidResourcePermission, id)
+		}
+	}
+}
+
+func TestResourcePermissionGranted(t *testing.T) {
+	r := New[string]()
+	editor := NewRole("editor")
+	assert(t, editor.Assign(NewResourcePermission("document", ResourceAny, "edit")))
+	assert(t, r.Add(editor))
+	assert(t, r.Add(NewRole("owner-42")))
+	assert(t, r.AssignWithin("owner-42", NewResourcePermission("document", "42", ResourceAny), Period{}))
+	if !r.IsGranted("editor", NewResourcePermission("document", "42", "edit"), nil) {
+		t.Fatal("[editor] should edit document 42")
+	}
+	if !r.IsGranted("owner-42", NewResourcePermission("document", "42", "delete"), nil) {
+		t.Fatal("[owner-42] should delete document 42")
+	}
+	if r.IsGranted("owner-42", NewResourcePermission("document", "43", "delete"), nil) {
+		t.Fatal("[owner-42] should not delete document 43")
+	}
+	assert(t, editor.Revoke(NewResourcePermission("document", ResourceAny, "edit")))
+	if r.IsGranted("editor", NewResourcePermission("document", "42", "edit"), nil) {
+		t.Fatal("[editor] should not edit documents after revoking")
+	}
+}
@@ -0,0 +1,298 @@
+package gorbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrInvalidPolicy occurred if a policy refers to something missing
+	ErrInvalidPolicy = errors.New("Invalid policy")
+	// ErrUnsupportedPermission occurred if a permission can't be
+	// represented by a policy format
+	ErrUnsupportedPermission = errors.New("Permission is not supported")
+)
+
+// Kinds of permissions in a policy
+const (
+	PermissionStd      = "std"
+	PermissionLayer    = "layer"
+	PermissionResource = "resource"
+	PermissionMask     = "mask"
+	PermissionRegex    = "regex"
+)
+
+// Policy is the serialisable form of RBAC, e.g. a policy file.
+// T must be usable as a JSON object key to be encoded as JSON, other IDs
+// are converted to strings by EncodePolicy.
+type Policy[T comparable] struct {
+	Roles map[T]PolicyRole[T] `json:"roles"`
+	// Subjects are the roles assigned to each subject
+	Subjects map[T][]T          `json:"subjects,omitempty"`
+	SSD      []SoDConstraint[T] `json:"ssd,omitempty"`
+	DSD      []SoDConstraint[T] `json:"dsd,omitempty"`
+	Limits   map[T]Limit        `json:"limits,omitempty"`
+	// Periods are the periods of time-bounded grants, assignments and
+	// inheritance edges
+	Periods []Expiry[T] `json:"periods,omitempty"`
+}
+
+// PolicyRole is a role in a Policy.
+type PolicyRole[T comparable] struct {
+	Permissions []PolicyPermission[T] `json:"permissions,omitempty"`
+	Parents     []T                   `json:"parents,omitempty"`
+	Metadata    Metadata              `json:"metadata,omitzero"`
+}
+
+// PolicyPermission wraps a built-in permission to be encoded as JSON.
+// A StdPermission is encoded as its ID, other permissions as an object
+// with their kind, e.g. `{"kind":"layer","id":"admin/users","sep":"/"}`.
+type PolicyPermission[T comparable] struct {
+	Permission[T]
+}
+
+// permissionKind returns the kind of a built-in permission.
+func permissionKind[T comparable](p Permission[T]) (string, error) {
+	switch any(p).(type) {
+	case StdPermission[T]:
+		return PermissionStd, nil
+	case LayerPermission:
+		return PermissionLayer, nil
+	case ResourcePermission:
+		return PermissionResource, nil
+	case MaskPermission:
+		return PermissionMask, nil
+	case RegexPermission:
+		return PermissionRegex, nil
+	}
+	return "", fmt.Errorf("%w: %T", ErrUnsupportedPermission, p)
+}
+
+// MarshalJSON encodes the permission by its kind
+func (p PolicyPermission[T]) MarshalJSON() ([]byte, error) {
+	kind, err := permissionKind(p.Permission)
+	if err != nil {
+		return nil, err
+	}
+	if kind == PermissionStd {
+		return json.Marshal(p.ID())
+	}
+	data, err := json.Marshal(p.Permission)
+	if err != nil {
+		return nil, err
+	}
+	// insert the kind as the first field of the object
+	return append([]byte(fmt.Sprintf(`{"kind":%q,`, kind)), data[1:]...), nil
+}
+
+// UnmarshalJSON decodes the permission by its kind.
+// Only StdPermission is supported unless T is string.
+func (p *PolicyPermission[T]) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Kind string `json:"kind"`
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := json.Unmarshal(data, &head); err != nil {
+			return err
+		}
+	}
+	if head.Kind == "" || head.Kind == PermissionStd {
+		var id T
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		p.Permission = NewPermission(id)
+		return nil
+	}
+	var q any
+	switch head.Kind {
+	case PermissionLayer:
+		var v LayerPermission
+		q = &v
+	case PermissionResource:
+		var v ResourcePermission
+		q = &v
+	case PermissionMask:
+		var v MaskPermission
+		q = &v
+	case PermissionRegex:
+		var v RegexPermission
+		q = &v
+	default:
+		return fmt.Errorf("%w: kind %q", ErrUnsupportedPermission, head.Kind)
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return err
+	}
+	var ok bool
+	switch v := q.(type) {
+	case *LayerPermission:
+		p.Permission, ok = any(*v).(Permission[T])
+	case *ResourcePermission:
+		p.Permission, ok = any(*v).(Permission[T])
+	case *MaskPermission:
+		p.Permission, ok = any(*v).(Permission[T])
+	case *RegexPermission:
+		p.Permission, ok = any(*v).(Permission[T])
+	}
+	if !ok {
+		return fmt.Errorf("%w: kind %q needs string IDs", ErrUnsupportedPermission, head.Kind)
+	}
+	return nil
+}
+
+// ExportPolicy returns the policy of `rbac`, sorted to be stable.
+// Only the built-in permissions can be encoded as JSON.
+func ExportPolicy[T comparable](rbac *RBAC[T]) *Policy[T] {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	p := &Policy[T]{Roles: make(map[T]PolicyRole[T], len(rbac.roles))}
+	for id, role := range rbac.roles {
+		pr := PolicyRole[T]{
+			Parents:  keys(rbac.parents[id]),
+			Metadata: metadataOf(role),
+		}
+		for _, q := range role.Permissions() {
+			pr.Permissions = append(pr.Permissions, PolicyPermission[T]{q})
+		}
+		p.Roles[id] = pr
+	}
+	for subject, roles := range rbac.subjects {
+		if p.Subjects == nil {
+			p.Subjects = make(map[T][]T)
+		}
+		p.Subjects[subject] = keys(roles)
+	}
+	for name := range rbac.ssd {
+		p.SSD = append(p.SSD, rbac.ssd[name].clone())
+	}
+	for name := range rbac.dsd {
+		p.DSD = append(p.DSD, rbac.dsd[name].clone())
+	}
+	for id, l := range rbac.limits {
+		if p.Limits == nil {
+			p.Limits = make(map[T]Limit)
+		}
+		p.Limits[id] = l
+	}
+	// every period, whether it's over or not
+	p.Periods = rbac.periods(func(Period) bool { return true })
+	p.normalize()
+	return p
+}
+
+// normalize sorts the lists of the policy, and makes empty ones nil,
+// so equal policies are deeply equal.
+func (p *Policy[T]) normalize() {
+	for id, pr := range p.Roles {
+		sortIDs(pr.Parents)
+		sort.SliceStable(pr.Permissions, func(i, j int) bool {
+			return fmt.Sprint(pr.Permissions[i].ID()) < fmt.Sprint(pr.Permissions[j].ID())
+		})
+		if len(pr.Parents) == 0 {
+			pr.Parents = nil
+		}
+		if len(pr.Permissions) == 0 {
+			pr.Permissions = nil
+		}
+		p.Roles[id] = pr
+	}
+	for _, roles := range p.Subjects {
+		sortIDs(roles)
+	}
+	for _, constraints := range [][]SoDConstraint[T]{p.SSD, p.DSD} {
+		sort.Slice(constraints, func(i, j int) bool {
+			return constraints[i].Name < constraints[j].Name
+		})
+	}
+	sort.Slice(p.Periods, func(i, j int) bool {
+		a, b := p.Periods[i], p.Periods[j]
+		return fmt.Sprint(a.Kind, " ", a.ID, " ", a.Target) < fmt.Sprint(b.Kind, " ", b.ID, " ", b.Target)
+	})
+}
+
+// ImportPolicy builds an RBAC from the policy `p`.
+// Constraints and limits are enforced while the policy is built, so
+// a policy breaking them is refused.
+func ImportPolicy[T comparable](p *Policy[T]) (*RBAC[T], error) {
+	rbac := New[T]()
+	for id, pr := range p.Roles {
+		role := NewRole(id)
+		role.Metadata = pr.Metadata
+		for _, q := range pr.Permissions {
+			if q.Permission == nil {
+				continue
+			}
+			if err := role.Assign(q.Permission); err != nil {
+				return nil, err
+			}
+		}
+		if err := rbac.Add(role); err != nil {
+			return nil, err
+		}
+	}
+	for id, l := range p.Limits {
+		if err := rbac.SetLimit(id, l); err != nil {
+			return nil, err
+		}
+	}
+	for id, pr := range p.Roles {
+		if len(pr.Parents) == 0 {
+			continue
+		}
+		if err := rbac.SetParents(id, pr.Parents); err != nil {
+			return nil, err
+		}
+	}
+	for subject, roles := range p.Subjects {
+		for _, id := range roles {
+			if err := rbac.AssignRole(subject, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, c := range p.SSD {
+		if err := rbac.AddSSD(c); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range p.DSD {
+		if err := rbac.AddDSD(c); err != nil {
+			return nil, err
+		}
+	}
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	for _, e := range p.Periods {
+		var entries map[T]map[T]Period
+		switch e.Kind {
+		case ExpiryGrant:
+			var q Permission[T]
+			role, ok := rbac.roles[e.ID]
+			if ok {
+				q = permissionOf(role, e.Target)
+			}
+			if q == nil {
+				return nil, fmt.Errorf("%w: %v isn't granted to %v", ErrInvalidPolicy, e.Target, e.ID)
+			}
+			if err := role.(TimedRole[T]).AssignWithin(q, e.Period); err != nil {
+				return nil, err
+			}
+			continue
+		case ExpiryAssignment:
+			entries = rbac.subjects
+		case ExpiryParent:
+			entries = rbac.parents
+		default:
+			return nil, fmt.Errorf("%w: unknown period kind %q", ErrInvalidPolicy, e.Kind)
+		}
+		if _, ok := entries[e.ID][e.Target]; !ok {
+			return nil, fmt.Errorf("%w: %s of %v to %v doesn't exist", ErrInvalidPolicy, e.Kind, e.Target, e.ID)
+		}
+		entries[e.ID][e.Target] = e.Period
+	}
+	return rbac, nil
+}
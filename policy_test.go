@@ -0,0 +1,127 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPolicy(t *testing.T) {
+	r := New[string]()
+	reports, err := NewRegexPermission(`^report-.*$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor := NewRole("editor")
+	editor.Metadata = Metadata{Name: "Editor", Tags: []string{"staff"}}
+	for _, p := range []Permission[string]{
+		NewPermission("write"),
+		NewLayerPermission("articles/edit", "/"),
+		NewResourcePermission("document", ResourceAny, "edit"),
+		NewMaskPermission("comment", ActionRead|ActionUpdate),
+		reports,
+	} {
+		assert(t, editor.Assign(p))
+	}
+	assert(t, r.Add(editor))
+	assert(t, r.Add(NewRole("reader")))
+	assert(t, r.Add(NewRole("auditor")))
+	assert(t, r.SetParent("editor", "reader"))
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert(t, r.AssignWithin("reader", NewPermission("read"), Period{NotAfter: until}))
+	assert(t, r.SetParentWithin("auditor", "reader", Period{NotAfter: until}))
+	assert(t, r.AssignRoleWithin("alice", "editor", Period{NotAfter: until}))
+	assert(t, r.AssignRole("bob", "auditor"))
+	assert(t, r.AddSSD(SoDConstraint[string]{"audit", []string{"editor", "auditor"}, 2}))
+	assert(t, r.AddDSD(SoDConstraint[string]{"session", []string{"reader", "auditor"}, 2}))
+	assert(t, r.SetLimit("auditor", Limit{Holders: 1}))
+
+	data, err := json.Marshal(ExportPolicy(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Policy[string]
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ImportPolicy(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ExportPolicy(r), ExportPolicy(loaded)) {
+		t.Fatalf("%s expected to be loaded", data)
+	}
+	if !loaded.IsGranted("editor", NewPermission("report-daily"), nil) {
+		t.Fatal("[report-daily] should be granted by the regex permission")
+	}
+	if !loaded.IsGranted("editor", NewMaskPermission("comment", ActionUpdate), nil) {
+		t.Fatal("[comment] should be granted by the mask permission")
+	}
+	loaded.SetClock(func() time.Time { return until.Add(time.Hour) })
+	if loaded.IsGranted("editor", NewPermission("read"), nil) {
+		t.Fatal("[read] should be expired")
+	}
+}
+
+func TestPolicyPermission(t *testing.T) {
+	var p PolicyPermission[string]
+	assert(t, json.Unmarshal([]byte(`{"kind":"layer","id":"a/b","sep":"/"}`), &p))
+	if p.Permission != NewLayerPermission("a/b", "/") {
+		t.Fatalf("A layer permission expected, but %#v got", p.Permission)
+	}
+	assert(t, json.Unmarshal([]byte(`"read"`), &p))
+	if p.Permission != NewPermission("read") {
+		t.Fatalf("A standard permission expected, but %#v got", p.Permission)
+	}
+	if err := json.Unmarshal([]byte(`{"kind":"unknown"}`), &p); !errors.Is(err, ErrUnsupportedPermission) {
+		t.Fatalf("%s expected, but %v got", ErrUnsupportedPermission, err)
+	}
+	var q PolicyPermission[int]
+	if err := json.Unmarshal([]byte(`{"kind":"mask","id":"a","actions":"read"}`), &q); !errors.Is(err, ErrUnsupportedPermission) {
+		t.Fatalf("%s expected, but %v got", ErrUnsupportedPermission, err)
+	}
+	assert(t, json.Unmarshal([]byte(`42`), &q))
+	if q.Permission != NewPermission(42) {
+		t.Fatalf("A standard permission expected, but %#v got", q.Permission)
+	}
+	if _, err := json.Marshal(PolicyPermission[string]{AllOf(NewPermission("a"))}); !errors.Is(err, ErrUnsupportedPermission) {
+		t.Fatalf("%s expected, but %v got", ErrUnsupportedPermission, err)
+	}
+}
+
+func TestImportPolicyErrors(t *testing.T) {
+	policies := []struct {
+		data     string
+		expected error
+	}{
+		{`{"roles":{"a":{"parents":["b"]}}}`, ErrRoleNotExist},
+		{`{"roles":{"a":{}},"periods":[{"kind":"grant","id":"a","target":"x","period":{}}]}`, ErrInvalidPolicy},
+		{`{"roles":{"a":{},"b":{}},"subjects":{"u":["a","b"]},` +
+			`"ssd":[{"name":"x","roles":["a","b"],"cardinality":2}]}`, ErrSSDViolation},
+	}
+	for _, c := range policies {
+		var p Policy[string]
+		assert(t, json.Unmarshal([]byte(c.data), &p))
+		if _, err := ImportPolicy(&p); !errors.Is(err, c.expected) {
+			t.Fatalf("%s expected for %s, but %v got", c.expected, c.data, err)
+		}
+	}
+}
+
+func TestImportPolicyCircle(t *testing.T) {
+	var p Policy[string]
+	assert(t, json.Unmarshal([]byte(`{"roles":{"a":{"parents":["b"]},"b":{"parents":["a"],"permissions":["y"]}}}`), &p))
+	r, err := ImportPolicy(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := InherCircle(r); !errors.Is(err, ErrFoundCircle) {
+		t.Fatalf("%s expected, but %v got", ErrFoundCircle, err)
+	}
+	// checking a circle inheritance terminates
+	if r.IsGranted("a", NewPermission("x"), nil) || !r.IsGranted("a", NewPermission("y"), nil) {
+		t.Fatal("Only [y] should be granted through the circle")
+	}
+}
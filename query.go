@@ -0,0 +1,145 @@
+package gorbac
+
+// Explanation tells why a role is granted a permission, or isn't.
+type Explanation[T comparable] struct {
+	Role       T    `json:"role"`
+	Permission T    `json:"permission"`
+	Granted    bool `json:"granted"`
+	// Path is the chain of inheritance from Role to the role holding
+	// the grant, both included
+	Path []T `json:"path,omitempty"`
+	// Grant is the ID of the assigned permission matching Permission.
+	// It's unknown if the role permits by itself without an assigned
+	// permission matching, e.g. a custom role.
+	Grant T `json:"grant"`
+}
+
+// Explain finds the shortest chain of inheritance by which the role `id`
+// is granted the permission `p` at the moment. CompositePermission is
+// explained as granted or not, without a path. An alias is explained as
+// the role it resolves to.
+func Explain[T comparable](rbac *RBAC[T], id T, p Permission[T]) (*Explanation[T], error) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	id = rbac.resolve(id)
+	if _, ok := rbac.roles[id]; !ok {
+		return nil, &RoleNotFoundError[T]{id}
+	}
+	e := &Explanation[T]{Role: id, Permission: p.ID()}
+	if _, ok := p.(CompositePermission[T]); ok {
+		e.Granted = rbac.isGranted(id, p, nil)
+		return e, nil
+	}
+	now := rbac.clock()
+	// breadth-first search over the edges valid at the moment
+	from := map[T]T{}
+	visited := map[T]struct{}{id: empty}
+	queue := []T{id}
+	for len(queue) > 0 {
+		rid := queue[0]
+		queue = queue[1:]
+		role, ok := rbac.roles[rid]
+		if !ok {
+			continue
+		}
+		if role.Permit(p) {
+			e.Granted = true
+			for cur := rid; ; cur = from[cur] {
+				e.Path = append([]T{cur}, e.Path...)
+				if cur == id {
+					break
+				}
+			}
+			periods := grantPeriods(role)
+			for _, rp := range role.Permissions() {
+				if period, ok := periods[rp.ID()]; ok && !period.Contains(now) {
+					continue
+				}
+				if rp.Match(p) {
+					e.Grant = rp.ID()
+					break
+				}
+			}
+			return e, nil
+		}
+		for _, parent := range sortedParents(rbac, rid) {
+			if _, ok := visited[parent]; ok {
+				continue
+			}
+			if !rbac.parents[rid][parent].Contains(now) {
+				continue
+			}
+			visited[parent] = empty
+			from[parent] = rid
+			queue = append(queue, parent)
+		}
+	}
+	return e, nil
+}
+
+// sortedParents returns the parents of the role `id` in a stable order.
+func sortedParents[T comparable](rbac *RBAC[T], id T) []T {
+	parents := keys(rbac.parents[id])
+	sortIDs(parents)
+	return parents
+}
+
+// WhoCan returns every role granted the permission `p` at the moment.
+func WhoCan[T comparable](rbac *RBAC[T], p Permission[T]) []T {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	var result []T
+	for id := range rbac.roles {
+		if rbac.isGranted(id, p, nil) {
+			result = append(result, id)
+		}
+	}
+	sortIDs(result)
+	return result
+}
+
+// Effective returns every permission assigned to the role `id` or to its
+// ancestors, which is valid at the moment. Permissions of the same ID
+// are returned once, preferring the one of the nearest role. An alias
+// is resolved to its role.
+func Effective[T comparable](rbac *RBAC[T], id T) ([]Permission[T], error) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	id = rbac.resolve(id)
+	if _, ok := rbac.roles[id]; !ok {
+		return nil, &RoleNotFoundError[T]{id}
+	}
+	now := rbac.clock()
+	permissions := make(Permissions[T])
+	visited := map[T]struct{}{id: empty}
+	queue := []T{id}
+	for len(queue) > 0 {
+		rid := queue[0]
+		queue = queue[1:]
+		if role, ok := rbac.roles[rid]; ok {
+			periods := grantPeriods(role)
+			for _, p := range role.Permissions() {
+				if period, ok := periods[p.ID()]; ok && !period.Contains(now) {
+					continue
+				}
+				if _, ok := permissions[p.ID()]; !ok {
+					permissions[p.ID()] = p
+				}
+			}
+		}
+		for _, parent := range sortedParents(rbac, rid) {
+			if _, ok := visited[parent]; ok || !rbac.parents[rid][parent].Contains(now) {
+				continue
+			}
+			visited[parent] = empty
+			queue = append(queue, parent)
+		}
+	}
+	ids := keys(permissions)
+	sortIDs(ids)
+	result := make([]Permission[T], len(ids))
+	for i, pid := range ids {
+		result[i] = permissions[pid]
+	}
+	return result, nil
+}
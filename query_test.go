@@ -0,0 +1,82 @@
+package gorbac
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func prepareQuery(t *testing.T) *RBAC[string] {
+	r := New[string]()
+	for id, ps := range map[string][]string{
+		"admin":  {"delete"},
+		"editor": {"write"},
+		"reader": {"read"},
+		"guest":  {"read"},
+	} {
+		role := NewRole(id)
+		for _, p := range ps {
+			assert(t, role.Assign(NewPermission(p)))
+		}
+		assert(t, r.Add(role))
+	}
+	assert(t, r.SetParents("admin", []string{"editor", "reader"}))
+	assert(t, r.SetParent("editor", "reader"))
+	return r
+}
+
+func TestExplain(t *testing.T) {
+	r := prepareQuery(t)
+	e, err := Explain(r, "admin", NewPermission("read"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Granted || e.Grant != "read" || !reflect.DeepEqual(e.Path, []string{"admin", "reader"}) {
+		t.Fatalf("The shortest path to [reader] expected, but %+v got", e)
+	}
+	e, err = Explain(r, "guest", NewPermission("write"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Granted || e.Path != nil {
+		t.Fatalf("[write] should be denied, but %+v got", e)
+	}
+	e, err = Explain(r, "editor", AllOf(NewPermission("read"), NewPermission("write")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Granted {
+		t.Fatal("A composite permission should be explained as granted")
+	}
+	if _, err := Explain(r, "nobody", NewPermission("read")); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleNotExist, err)
+	}
+}
+
+func TestWhoCan(t *testing.T) {
+	r := prepareQuery(t)
+	if roles := WhoCan(r, NewPermission("write")); !reflect.DeepEqual(roles, []string{"admin", "editor"}) {
+		t.Fatalf("[admin editor] expected, but %v got", roles)
+	}
+	if roles := WhoCan(r, NewPermission("unknown")); len(roles) != 0 {
+		t.Fatalf("No role expected, but %v got", roles)
+	}
+}
+
+func TestEffective(t *testing.T) {
+	r := prepareQuery(t)
+	permissions, err := Effective(r, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range permissions {
+		ids = append(ids, p.ID())
+	}
+	if !reflect.DeepEqual(ids, []string{"delete", "read", "write"}) {
+		t.Fatalf("[delete read write] expected, but %v got", ids)
+	}
+	if _, err := Effective(r, "nobody"); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleNotExist, err)
+	}
+}
@@ -13,12 +13,16 @@ Thus, RBAC has the following model:
 	* many to many relationship between identities and roles.
 	* many to many relationship between roles and permissions.
 	* roles can have parent roles.
+	* subjects are assigned roles, subject to separation of duty
+	  constraints.
 */
 package gorbac
 
 import (
 	"errors"
+	"fmt"
 	"sync"
+	"time"
 )
 
 var (
@@ -29,22 +33,62 @@ var (
 	empty        = struct{}{}
 )
 
+// RoleNotFoundError carries the ID of the missing role.
+// It wraps ErrRoleNotExist.
+type RoleNotFoundError[T comparable] struct {
+	ID T
+}
+
+func (e *RoleNotFoundError[T]) Error() string {
+	return fmt.Sprintf("%s: %v", ErrRoleNotExist, e.ID)
+}
+
+func (e *RoleNotFoundError[T]) Unwrap() error {
+	return ErrRoleNotExist
+}
+
+// RoleExistError carries the ID of the existing role.
+// It wraps ErrRoleExist.
+type RoleExistError[T comparable] struct {
+	ID T
+}
+
+func (e *RoleExistError[T]) Error() string {
+	return fmt.Sprintf("%s: %v", ErrRoleExist, e.ID)
+}
+
+func (e *RoleExistError[T]) Unwrap() error {
+	return ErrRoleExist
+}
+
 // AssertionFunc supplies more fine-grained permission controls.
 type AssertionFunc[T comparable] func(*RBAC[T], T, Permission[T]) bool
 
 // RBAC object, in most cases it should be used as a singleton.
 type RBAC[T comparable] struct {
-	mutex   sync.RWMutex
-	roles   Roles[T]
-	parents map[T]map[T]struct{}
+	mutex    sync.RWMutex
+	roles    Roles[T]
+	parents  map[T]map[T]Period
+	subjects map[T]map[T]Period
+	ssd      map[string]SoDConstraint[T]
+	dsd      map[string]SoDConstraint[T]
+	limits   map[T]Limit
+	aliases  map[T]T
+	clock    func() time.Time
 }
 
 // New returns a RBAC structure.
 // The default role structure will be used.
 func New[T comparable]() *RBAC[T] {
 	return &RBAC[T]{
-		roles:   make(Roles[T]),
-		parents: make(map[T]map[T]struct{}),
+		roles:    make(Roles[T]),
+		parents:  make(map[T]map[T]Period),
+		subjects: make(map[T]map[T]Period),
+		ssd:      make(map[string]SoDConstraint[T]),
+		dsd:      make(map[string]SoDConstraint[T]),
+		limits:   make(map[T]Limit),
+		aliases:  make(map[T]T),
+		clock:    time.Now,
 	}
 }
 
@@ -55,20 +99,14 @@ func (rbac *RBAC[T]) SetParents(id T, parents []T) error {
 	rbac.mutex.Lock()
 	defer rbac.mutex.Unlock()
 	if _, ok := rbac.roles[id]; !ok {
-		return ErrRoleNotExist
+		return &RoleNotFoundError[T]{id}
 	}
 	for _, parent := range parents {
 		if _, ok := rbac.roles[parent]; !ok {
-			return ErrRoleNotExist
+			return &RoleNotFoundError[T]{parent}
 		}
 	}
-	if _, ok := rbac.parents[id]; !ok {
-		rbac.parents[id] = make(map[T]struct{})
-	}
-	for _, parent := range parents {
-		rbac.parents[id][parent] = empty
-	}
-	return nil
+	return rbac.bindParents(id, parents, Period{})
 }
 
 // GetParents return `parents` of the role `id`.
@@ -79,7 +117,7 @@ func (rbac *RBAC[T]) GetParents(id T) ([]T, error) {
 	rbac.mutex.Lock()
 	defer rbac.mutex.Unlock()
 	if _, ok := rbac.roles[id]; !ok {
-		return nil, ErrRoleNotExist
+		return nil, &RoleNotFoundError[T]{id}
 	}
 	ids, ok := rbac.parents[id]
 	if !ok {
@@ -99,16 +137,41 @@ func (rbac *RBAC[T]) SetParent(id T, parent T) error {
 	rbac.mutex.Lock()
 	defer rbac.mutex.Unlock()
 	if _, ok := rbac.roles[id]; !ok {
-		return ErrRoleNotExist
+		return &RoleNotFoundError[T]{id}
 	}
 	if _, ok := rbac.roles[parent]; !ok {
-		return ErrRoleNotExist
+		return &RoleNotFoundError[T]{parent}
 	}
+	return rbac.bindParents(id, []T{parent}, Period{})
+}
+
+// bindParents binds `parents` to the role `id` within `period`, unless
+// the new edges would break a static separation of duty constraint.
+func (rbac *RBAC[T]) bindParents(id T, parents []T, period Period) error {
 	if _, ok := rbac.parents[id]; !ok {
-		rbac.parents[id] = make(map[T]struct{})
+		rbac.parents[id] = make(map[T]Period)
+	}
+	previous := make(map[T]Period)
+	for _, parent := range parents {
+		if old, ok := rbac.parents[id][parent]; ok {
+			previous[parent] = old
+		}
+		rbac.parents[id][parent] = period
+	}
+	err := rbac.checkParents(id)
+	if err == nil {
+		err = rbac.checkInheritSSD(id)
+	}
+	if err != nil {
+		for _, parent := range parents {
+			if old, ok := previous[parent]; ok {
+				rbac.parents[id][parent] = old
+			} else {
+				delete(rbac.parents[id], parent)
+			}
+		}
+		return err
 	}
-	var empty struct{}
-	rbac.parents[id][parent] = empty
 	return nil
 }
 
@@ -119,27 +182,37 @@ func (rbac *RBAC[T]) RemoveParent(id T, parent T) error {
 	rbac.mutex.Lock()
 	defer rbac.mutex.Unlock()
 	if _, ok := rbac.roles[id]; !ok {
-		return ErrRoleNotExist
+		return &RoleNotFoundError[T]{id}
 	}
 	if _, ok := rbac.roles[parent]; !ok {
-		return ErrRoleNotExist
+		return &RoleNotFoundError[T]{parent}
 	}
 	delete(rbac.parents[id], parent)
 	return nil
 }
 
 // Add a role `r`.
+// The role is kept as it is, Get returns the same value.
 func (rbac *RBAC[T]) Add(r Role[T]) (err error) {
 	rbac.mutex.Lock()
-	if _, ok := rbac.roles[r.ID]; !ok {
-		rbac.roles[r.ID] = r
+	if _, ok := rbac.roles[r.ID()]; !ok {
+		rbac.add(r)
 	} else {
-		err = ErrRoleExist
+		err = &RoleExistError[T]{r.ID()}
 	}
 	rbac.mutex.Unlock()
 	return
 }
 
+// add the role `r`, evaluating the periods of its grants by the clock of
+// the RBAC.
+func (rbac *RBAC[T]) add(r Role[T]) {
+	if tr, ok := r.(TimedRole[T]); ok {
+		tr.SetClock(rbac.now)
+	}
+	rbac.roles[r.ID()] = r
+}
+
 // Remove the role by `id`.
 func (rbac *RBAC[T]) Remove(id T) (err error) {
 	rbac.mutex.Lock()
@@ -157,29 +230,103 @@ func (rbac *RBAC[T]) Remove(id T) (err error) {
 				}
 			}
 		}
+		delete(rbac.limits, id)
+		for _, roles := range rbac.subjects {
+			delete(roles, id)
+		}
+		removeFromSoD(rbac.ssd, id)
+		removeFromSoD(rbac.dsd, id)
+		for alias, rid := range rbac.aliases {
+			if rid == id {
+				delete(rbac.aliases, alias)
+			}
+		}
 	} else {
-		err = ErrRoleNotExist
+		err = &RoleNotFoundError[T]{id}
 	}
 	rbac.mutex.Unlock()
 	return
 }
 
 // Get the role by `id` and a slice of its parents id.
+// An alias returns the role it resolves to.
 func (rbac *RBAC[T]) Get(id T) (r Role[T], parents []T, err error) {
 	rbac.mutex.RLock()
+	id = rbac.resolve(id)
 	var ok bool
 	if r, ok = rbac.roles[id]; ok {
 		for parent := range rbac.parents[id] {
 			parents = append(parents, parent)
 		}
 	} else {
-		err = ErrRoleNotExist
+		err = &RoleNotFoundError[T]{id}
 	}
 	rbac.mutex.RUnlock()
 	return
 }
 
+// AssignRole assigns the role `id` to the subject.
+// If the role is not existing, or the assignment would break a
+// static separation of duty constraint or the limit of the role,
+// an error will be returned.
+func (rbac *RBAC[T]) AssignRole(subject T, id T) error {
+	return rbac.AssignRoleWithin(subject, id, Period{})
+}
+
+// AssignRoleWithin assigns the role `id` to the subject, valid only
+// within `period`.
+func (rbac *RBAC[T]) AssignRoleWithin(subject T, id T, period Period) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.roles[id]; !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	// an expired assignment isn't a holder any more
+	if current, ok := rbac.subjects[subject][id]; !ok || current.Expired(rbac.now()) {
+		if err := rbac.checkHolders(id); err != nil {
+			return err
+		}
+	}
+	roles := append(keys(rbac.subjects[subject]), id)
+	if err := rbac.checkSSD(subject, roles); err != nil {
+		return err
+	}
+	if _, ok := rbac.subjects[subject]; !ok {
+		rbac.subjects[subject] = make(map[T]Period)
+	}
+	rbac.subjects[subject][id] = period
+	return nil
+}
+
+// RevokeRole removes the role `id` from the subject.
+// If the role is not existing, an error will be returned.
+func (rbac *RBAC[T]) RevokeRole(subject T, id T) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.roles[id]; !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	delete(rbac.subjects[subject], id)
+	if len(rbac.subjects[subject]) == 0 {
+		delete(rbac.subjects, subject)
+	}
+	return nil
+}
+
+// GetRoles returns the roles directly assigned to the subject.
+// If the subject doesn't have any roles, a nil slice will be returned.
+func (rbac *RBAC[T]) GetRoles(subject T) []T {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	var roles []T
+	for id := range rbac.subjects[subject] {
+		roles = append(roles, id)
+	}
+	return roles
+}
+
 // IsGranted tests if the role `id` has Permission `p` with the condition `assert`.
+// An alias of a role is resolved to the role.
 func (rbac *RBAC[T]) IsGranted(id T, p Permission[T],
 	assert AssertionFunc[T]) (ok bool) {
 	rbac.mutex.RLock()
@@ -190,21 +337,42 @@ func (rbac *RBAC[T]) IsGranted(id T, p Permission[T],
 
 func (rbac *RBAC[T]) isGranted(id T, p Permission[T],
 	assert AssertionFunc[T]) bool {
+	id = rbac.resolve(id)
 	if assert != nil && !assert(rbac, id, p) {
 		return false
 	}
-	return rbac.recursionCheck(id, p)
+	now := rbac.clock()
+	if c, ok := p.(CompositePermission[T]); ok {
+		return c.Evaluate(func(q Permission[T]) bool {
+			return rbac.recursionCheck(id, q, now)
+		})
+	}
+	return rbac.recursionCheck(id, p, now)
+}
+
+// recursionCheck tests if the role `id` or its ancestors permit `p`.
+// Each role is visited once, so a circle inheritance can't recurse
+// forever.
+func (rbac *RBAC[T]) recursionCheck(id T, p Permission[T], now time.Time) bool {
+	return rbac.permitted(id, p, now, make(map[T]struct{}))
 }
 
-func (rbac *RBAC[T]) recursionCheck(id T, p Permission[T]) bool {
+func (rbac *RBAC[T]) permitted(id T, p Permission[T], now time.Time, visited map[T]struct{}) bool {
+	if _, ok := visited[id]; ok {
+		return false
+	}
+	visited[id] = empty
 	if role, ok := rbac.roles[id]; ok {
 		if role.Permit(p) {
 			return true
 		}
 		if parents, ok := rbac.parents[id]; ok {
-			for pID := range parents {
+			for pID, period := range parents {
+				if !period.Contains(now) {
+					continue
+				}
 				if _, ok := rbac.roles[pID]; ok {
-					if rbac.recursionCheck(pID, p) {
+					if rbac.permitted(pID, p, now, visited) {
 						return true
 					}
 				}
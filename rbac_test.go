@@ -1,6 +1,7 @@
 package gorbac
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -33,7 +34,7 @@ func TestRbacPrepare(t *testing.T) {
 
 func TestRbacAdd(t *testing.T) {
 	assert(t, rbac.Add(rA))
-	if err := rbac.Add(rA); err != ErrRoleExist {
+	if err := rbac.Add(rA); !errors.Is(err, ErrRoleExist) {
 		t.Error("A role can not be readded")
 	}
 	assert(t, rbac.Add(rB))
@@ -45,8 +46,8 @@ func TestRbacGetRemove(t *testing.T) {
 	assert(t, rbac.SetParent("role-a", "role-b"))
 	if r, parents, err := rbac.Get("role-a"); err != nil {
 		t.Fatal(err)
-	} else if r.ID != "role-a" {
-		t.Fatalf("[role-a] does not match %s", r.ID)
+	} else if r.ID() != "role-a" {
+		t.Fatalf("[role-a] does not match %s", r.ID())
 	} else if len(parents) != 1 {
 		t.Fatal("[role-a] should have one parent")
 	}
@@ -54,13 +55,13 @@ func TestRbacGetRemove(t *testing.T) {
 	if _, ok := rbac.roles["role-a"]; ok {
 		t.Fatal("Role removing failed")
 	}
-	if err := rbac.Remove("not-exist"); err != ErrRoleNotExist {
+	if err := rbac.Remove("not-exist"); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
 	}
-	if r, parents, err := rbac.Get("role-a"); err != ErrRoleNotExist {
+	if r, parents, err := rbac.Get("role-a"); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
-	} else if r.ID != "" {
-		t.Fatal("The ID of a role should be empty")
+	} else if r != nil {
+		t.Fatal("The role should be a nil")
 	} else if parents != nil {
 		t.Fatal("The slice of parents should be a nil")
 	}
@@ -75,29 +76,29 @@ func TestRbacParents(t *testing.T) {
 	if _, ok := rbac.parents["role-c"]["role-b"]; ok {
 		t.Fatal("Parent unbinding failed")
 	}
-	if err := rbac.RemoveParent("role-a", "role-b"); err != ErrRoleNotExist {
+	if err := rbac.RemoveParent("role-a", "role-b"); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
 	}
-	if err := rbac.RemoveParent("role-b", "role-a"); err != ErrRoleNotExist {
+	if err := rbac.RemoveParent("role-b", "role-a"); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
 	}
-	if err := rbac.SetParent("role-a", "role-b"); err != ErrRoleNotExist {
+	if err := rbac.SetParent("role-a", "role-b"); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
 	}
-	if err := rbac.SetParent("role-c", "role-a"); err != ErrRoleNotExist {
+	if err := rbac.SetParent("role-c", "role-a"); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
 	}
-	if err := rbac.SetParents("role-a", []string{"role-b"}); err != ErrRoleNotExist {
+	if err := rbac.SetParents("role-a", []string{"role-b"}); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
 	}
-	if err := rbac.SetParents("role-c", []string{"role-a"}); err != ErrRoleNotExist {
+	if err := rbac.SetParents("role-c", []string{"role-a"}); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
 	}
 	assert(t, rbac.SetParents("role-c", []string{"role-b"}))
 	if _, ok := rbac.parents["role-c"]["role-b"]; !ok {
 		t.Fatal("Parent binding failed")
 	}
-	if parents, err := rbac.GetParents("role-a"); err != ErrRoleNotExist {
+	if parents, err := rbac.GetParents("role-a"); !errors.Is(err, ErrRoleNotExist) {
 		t.Fatalf("%s needed", ErrRoleNotExist)
 	} else if len(parents) != 0 {
 		t.Fatal("[role-a] should not have any parent")
@@ -160,3 +161,44 @@ func BenchmarkRbacNotGranted(b *testing.B) {
 		rbac.IsGranted("role-a", pB, nil)
 	}
 }
+
+func TestRbacSubjects(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(NewRole("role-x")))
+	assert(t, r.Add(NewRole("role-y")))
+	if err := r.AssignRole("user", "not-exist"); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s needed", ErrRoleNotExist)
+	}
+	assert(t, r.AssignRole("user", "role-x"))
+	assert(t, r.AssignRole("user", "role-y"))
+	if roles := r.GetRoles("user"); len(roles) != 2 {
+		t.Fatalf("[user] should have two roles, but %v got", roles)
+	}
+	assert(t, r.Remove("role-x"))
+	if roles := r.GetRoles("user"); len(roles) != 1 || roles[0] != "role-y" {
+		t.Fatalf("[user] should only have role-y, but %v got", roles)
+	}
+	assert(t, r.RevokeRole("user", "role-y"))
+	if roles := r.GetRoles("user"); roles != nil {
+		t.Fatalf("[user] should not have any role, but %v got", roles)
+	}
+}
+
+func TestRbacErrors(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(NewRole("role-x")))
+	assert(t, r.Add(NewRole("role-y")))
+	err := r.SetParents("role-x", []string{"role-y", "role-missing"})
+	var notFound *RoleNotFoundError[string]
+	if !errors.As(err, &notFound) || !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("RoleNotFoundError needed, but %v got", err)
+	}
+	if notFound.ID != "role-missing" {
+		t.Fatalf("[role-missing] expected, but %s got", notFound.ID)
+	}
+	err = r.Add(NewRole("role-x"))
+	var exist *RoleExistError[string]
+	if !errors.As(err, &exist) || !errors.Is(err, ErrRoleExist) || exist.ID != "role-x" {
+		t.Fatalf("RoleExistError of [role-x] needed, but %v got", err)
+	}
+}
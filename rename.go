@@ -0,0 +1,132 @@
+package gorbac
+
+import "errors"
+
+var (
+	// ErrRenameNotSupported occurred if a role doesn't implement
+	// RenameableRole
+	ErrRenameNotSupported = errors.New("Role does not support renaming")
+)
+
+// Rename the role `id` to `to` atomically, keeping its permissions,
+// parents and children, and moving its grants, assignments, constraints,
+// limits and aliases to the new ID. Template instances and sessions
+// follow the role, as they track it rather than its ID. IDs kept
+// elsewhere, e.g. in exported policies, expectations or the IDs of
+// permissions, aren't changed. The role must implement RenameableRole,
+// and `to` must be neither a role nor an alias of another role.
+func (rbac *RBAC[T]) Rename(id, to T) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	role, ok := rbac.roles[id]
+	if !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	if id == to {
+		return nil
+	}
+	if _, ok := rbac.roles[to]; ok {
+		return &RoleExistError[T]{to}
+	}
+	if rid, ok := rbac.aliases[to]; ok && rid != id {
+		return &RoleExistError[T]{to}
+	}
+	rr, ok := role.(RenameableRole[T])
+	if !ok {
+		return ErrRenameNotSupported
+	}
+	// renaming back to an alias of the role drops the alias
+	delete(rbac.aliases, to)
+	rr.SetID(to)
+	delete(rbac.roles, id)
+	rbac.roles[to] = role
+	renameKey(rbac.parents, id, to)
+	for _, parents := range rbac.parents {
+		renameKey(parents, id, to)
+	}
+	for _, roles := range rbac.subjects {
+		renameKey(roles, id, to)
+	}
+	renameInSoD(rbac.ssd, id, to)
+	renameInSoD(rbac.dsd, id, to)
+	renameKey(rbac.limits, id, to)
+	for alias, rid := range rbac.aliases {
+		if rid == id {
+			rbac.aliases[alias] = to
+		}
+	}
+	return nil
+}
+
+// renameKey moves the value of the key `id` to `to`.
+func renameKey[T comparable, V any](m map[T]V, id, to T) {
+	if v, ok := m[id]; ok {
+		delete(m, id)
+		m[to] = v
+	}
+}
+
+func renameInSoD[T comparable](constraints map[string]SoDConstraint[T], id, to T) {
+	for name, c := range constraints {
+		roles := make([]T, len(c.Roles))
+		for i, rid := range c.Roles {
+			if rid == id {
+				rid = to
+			}
+			roles[i] = rid
+		}
+		c.Roles = roles
+		constraints[name] = c
+	}
+}
+
+// SetAlias makes `alias` resolve to the role `id` when checking
+// permissions, e.g. the old ID of a renamed role during a migration.
+// An alias can't be the ID of a role, nor be redirected to another role
+// without being removed first. It follows the role when it's renamed,
+// and is removed with the role.
+func (rbac *RBAC[T]) SetAlias(alias, id T) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.roles[id]; !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	if _, ok := rbac.roles[alias]; ok {
+		return &RoleExistError[T]{alias}
+	}
+	if rid, ok := rbac.aliases[alias]; ok && rid != id {
+		return &RoleExistError[T]{alias}
+	}
+	rbac.aliases[alias] = id
+	return nil
+}
+
+// RemoveAlias removes the `alias`, e.g. at the end of a migration.
+func (rbac *RBAC[T]) RemoveAlias(alias T) {
+	rbac.mutex.Lock()
+	delete(rbac.aliases, alias)
+	rbac.mutex.Unlock()
+}
+
+// Aliases returns every alias and the role it resolves to.
+func (rbac *RBAC[T]) Aliases() map[T]T {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	result := make(map[T]T, len(rbac.aliases))
+	for alias, id := range rbac.aliases {
+		result[alias] = id
+	}
+	return result
+}
+
+// resolve returns the role the alias `id` resolves to, or `id` itself.
+// A role added with the ID of an alias takes precedence.
+func (rbac *RBAC[T]) resolve(id T) T {
+	if _, ok := rbac.roles[id]; ok {
+		return id
+	}
+	if rid, ok := rbac.aliases[id]; ok {
+		return rid
+	}
+	return id
+}
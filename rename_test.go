@@ -0,0 +1,170 @@
+package gorbac
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRename(t *testing.T) {
+	r := New[string]()
+	editor := NewRole("editor")
+	assert(t, editor.Assign(NewPermission("write")))
+	assert(t, r.Add(editor))
+	assert(t, r.Add(NewRole("reader")))
+	assert(t, r.Add(NewRole("chief")))
+	assert(t, r.Add(NewRole("auditor")))
+	assert(t, r.SetParent("editor", "reader"))
+	assert(t, r.SetParent("chief", "editor"))
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert(t, r.AssignWithin("editor", NewPermission("publish"), Period{NotAfter: until}))
+	assert(t, r.AssignRole("alice", "editor"))
+	assert(t, r.AddSSD(SoDConstraint[string]{"audit", []string{"editor", "auditor"}, 2}))
+	assert(t, r.SetLimit("editor", Limit{Holders: 1}))
+
+	assert(t, r.Rename("editor", "writer"))
+	if _, _, err := r.Get("editor"); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleNotExist, err)
+	}
+	role, parents, err := r.Get("writer")
+	assert(t, err)
+	if role.ID() != "writer" || !reflect.DeepEqual(parents, []string{"reader"}) {
+		t.Fatalf("writer inheriting reader expected, but %s %v got", role.ID(), parents)
+	}
+	if !r.IsGranted("chief", NewPermission("write"), nil) {
+		t.Fatal("[write] should be inherited by chief")
+	}
+	if roles := r.GetRoles("alice"); !reflect.DeepEqual(roles, []string{"writer"}) {
+		t.Fatalf("[writer] expected, but %v got", roles)
+	}
+	if err := r.AssignRole("alice", "auditor"); !errors.Is(err, ErrSSDViolation) {
+		t.Fatalf("%s expected, but %v got", ErrSSDViolation, err)
+	}
+	if l, _ := r.GetLimit("writer"); l.Holders != 1 {
+		t.Fatalf("The limit expected to be moved, but %+v got", l)
+	}
+	if expiries := ExportPolicy(r).Periods; len(expiries) != 1 || expiries[0].ID != "writer" {
+		t.Fatalf("The period expected to be moved, but %v got", expiries)
+	}
+
+	if err := r.Rename("writer", "reader"); !errors.Is(err, ErrRoleExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleExist, err)
+	}
+	if err := r.Rename("nobody", "somebody"); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleNotExist, err)
+	}
+	assert(t, r.Add(struct{ Role[string] }{NewRole("fixed")}))
+	if err := r.Rename("fixed", "moved"); !errors.Is(err, ErrRenameNotSupported) {
+		t.Fatalf("%s expected, but %v got", ErrRenameNotSupported, err)
+	}
+}
+
+func TestRenameInstanceAndSession(t *testing.T) {
+	r := New[string]()
+	ts := NewTemplates(r)
+	team := Template{
+		Name:        "team",
+		Params:      []string{"team"},
+		ID:          "team-{team}",
+		Permissions: []Permission[string]{NewPermission("read-{team}")},
+	}
+	assert(t, ts.Define(team))
+	_, err := ts.Instantiate("team", map[string]string{"team": "a"})
+	assert(t, err)
+	assert(t, r.AssignRole("alice", "team-a"))
+	s := r.NewSession("alice")
+	assert(t, s.Activate("team-a"))
+
+	assert(t, r.Rename("team-a", "squad-a"))
+	if instances := ts.Instances("team"); !reflect.DeepEqual(instances, []string{"squad-a"}) {
+		t.Fatalf("[squad-a] expected, but %v got", instances)
+	}
+	if name, _, ok := ts.InstanceOf("squad-a"); !ok || name != "team" {
+		t.Fatal("[squad-a] should be an instance of team")
+	}
+	if !s.IsGranted(NewPermission("read-a"), nil) {
+		t.Fatal("[squad-a] should stay active when renamed")
+	}
+	if roles := s.ActiveRoles(); !reflect.DeepEqual(roles, []string{"squad-a"}) {
+		t.Fatalf("[squad-a] expected, but %v got", roles)
+	}
+
+	// a role added by the old ID isn't an instance
+	other := NewRole("team-a")
+	assert(t, other.Assign(NewPermission("other")))
+	assert(t, r.Add(other))
+	team.Permissions = []Permission[string]{NewPermission("write-{team}")}
+	assert(t, ts.Define(team))
+	if !r.IsGranted("squad-a", NewPermission("write-a"), nil) || r.IsGranted("squad-a", NewPermission("read-a"), nil) {
+		t.Fatal("The renamed instance should be updated")
+	}
+	if len(other.Permissions()) != 1 || !other.Permit(NewPermission("other")) {
+		t.Fatal("The role added by the old ID should not be updated")
+	}
+	assert(t, s.Deactivate("squad-a"))
+}
+
+func TestAlias(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(NewRole("editor")))
+	assert(t, r.Add(NewRole("reader")))
+	assert(t, roleOf(t, r, "editor").Assign(NewPermission("write")))
+	assert(t, r.Rename("editor", "writer"))
+	assert(t, r.SetAlias("editor", "writer"))
+	if !r.IsGranted("editor", NewPermission("write"), nil) {
+		t.Fatal("[write] should be granted by the alias")
+	}
+	// every entry point resolves the alias
+	if role, _, err := r.Get("editor"); err != nil || role.ID() != "writer" {
+		t.Fatalf("[writer] expected by the alias, but %v, %v got", role, err)
+	}
+	e, err := Explain(r, "editor", NewPermission("write"))
+	if err != nil || !e.Granted || !reflect.DeepEqual(e.Path, []string{"writer"}) {
+		t.Fatalf("[write] should be explained by the alias, but %v, %v got", e, err)
+	}
+	if ps, err := Effective(r, "editor"); err != nil || len(ps) != 1 || ps[0].ID() != "write" {
+		t.Fatalf("[write] should be effective by the alias, but %v, %v got", ps, err)
+	}
+	assert(t, r.AssignRole("alice", "writer"))
+	s := r.NewSession("alice")
+	assert(t, s.Activate("editor"))
+	if !reflect.DeepEqual(s.ActiveRoles(), []string{"writer"}) || !s.IsGranted(NewPermission("write"), nil) {
+		t.Fatal("[writer] should be activated by the alias")
+	}
+	assert(t, s.Deactivate("editor"))
+	// an alias isn't redirected silently
+	assert(t, r.SetAlias("editor", "writer"))
+	if err := r.SetAlias("editor", "reader"); !errors.Is(err, ErrRoleExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleExist, err)
+	}
+	if err := r.SetAlias("reader", "writer"); !errors.Is(err, ErrRoleExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleExist, err)
+	}
+	if err := r.SetAlias("x", "nobody"); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleNotExist, err)
+	}
+	if err := r.Rename("reader", "editor"); !errors.Is(err, ErrRoleExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleExist, err)
+	}
+
+	// an alias follows its role
+	assert(t, r.Rename("writer", "author"))
+	if aliases := r.Aliases(); !reflect.DeepEqual(aliases, map[string]string{"editor": "author"}) {
+		t.Fatalf("editor -> author expected, but %v got", aliases)
+	}
+	assert(t, r.Rename("author", "editor"))
+	if aliases := r.Aliases(); len(aliases) != 0 {
+		t.Fatalf("No alias expected, but %v got", aliases)
+	}
+	assert(t, r.SetAlias("author", "editor"))
+	r.RemoveAlias("author")
+	if r.IsGranted("author", NewPermission("write"), nil) {
+		t.Fatal("[write] shouldn't be granted by a removed alias")
+	}
+	assert(t, r.SetAlias("author", "editor"))
+	assert(t, r.Remove("editor"))
+	if aliases := r.Aliases(); len(aliases) != 0 {
+		t.Fatalf("No alias expected, but %v got", aliases)
+	}
+}
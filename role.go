@@ -1,67 +1,236 @@
 package gorbac
 
 import (
+	"strings"
 	"sync"
+	"time"
 )
 
+// Role is the interface of roles kept by RBAC.
+// You can implement it for your own data structure,
+// or embed StdRole into it.
+// T is the type of ID
+type Role[T comparable] interface {
+	ID() T
+	Permit(Permission[T]) bool
+	Assign(Permission[T]) error
+	Revoke(Permission[T]) error
+	Permissions() []Permission[T]
+}
+
+// MetadataRole is implemented by roles carrying Metadata, e.g. StdRole.
+type MetadataRole interface {
+	GetMetadata() Metadata
+	SetMetadata(Metadata)
+}
+
+// RenameableRole is implemented by roles whose ID can be changed by
+// RBAC.Rename, e.g. StdRole.
+type RenameableRole[T comparable] interface {
+	SetID(T)
+}
+
+// TimedRole is implemented by roles keeping the periods of their grants,
+// e.g. StdRole. RBAC.AssignWithin needs it, and RBAC sets the clock of
+// the role to its own when the role is added.
+type TimedRole[T comparable] interface {
+	AssignWithin(Permission[T], Period) error
+	Periods() map[T]Period
+	SetClock(func() time.Time)
+}
+
 // Roles is a map
 type Roles[T comparable] map[T]Role[T]
 
-// NewStdRole is the default role factory function.
-// It matches the declaration to RoleFactoryFunc.
-func NewRole[T comparable](id T) Role[T] {
-	return Role[T]{
-		ID:          id,
+// NewRole is the default role factory function.
+func NewRole[T comparable](id T) *StdRole[T] {
+	return &StdRole[T]{
+		SID:         id,
 		permissions: make(Permissions[T]),
+		layers:      make(map[string]*layerTrie),
+		opaque:      make(Permissions[T]),
 	}
 }
 
 // StdRole is the default role implement.
 // You can combine this struct into your own Role implement.
 // T is the type of ID
-type Role[T comparable] struct {
+//
+// Permissions are indexed by kind: every permission by ID, so
+// StdPermission and MaskPermission are found by a hash lookup,
+// LayerPermission in a trie of layers for each separator, and only
+// other permissions are scanned one by one calling Match. A
+// time-bounded LayerPermission is scanned too, as the trie doesn't
+// know the periods.
+type StdRole[T comparable] struct {
 	sync.RWMutex
-	// ID is the serialisable identity of role
-	ID          T `json:"id"`
+	// SID is the serialisable identity of role
+	SID T `json:"id"`
+	// Metadata describes the role
+	Metadata    Metadata `json:"metadata,omitzero"`
 	permissions Permissions[T]
+	layers      map[string]*layerTrie
+	opaque      Permissions[T]
+	// periods of the time-bounded grants
+	periods map[T]Period
+	clock   func() time.Time
+}
+
+// ID returns the identity of role
+func (role *StdRole[T]) ID() T {
+	return role.SID
 }
 
 // Assign a permission to the role.
-func (role *Role[T]) Assign(p Permission[T]) error {
+// A MergeablePermission is merged into the assigned one of the same ID,
+// unless that one is time-bounded, then it's replaced.
+func (role *StdRole[T]) Assign(p Permission[T]) error {
+	return role.AssignWithin(p, Period{})
+}
+
+// AssignWithin assigns a permission to the role, valid only within
+// `period`. The period is part of the grant: revoking the permission, or
+// assigning it again, drops it. A MergeablePermission is merged into the
+// assigned one of the same ID and period, and replaces one of another
+// period.
+func (role *StdRole[T]) AssignWithin(p Permission[T], period Period) error {
+	if _, ok := p.(CompositePermission[T]); ok {
+		return ErrCompositeAssign
+	}
 	role.Lock()
-	role.permissions[p.ID()] = p
+	if mp, ok := role.permissions[p.ID()].(MergeablePermission[T]); ok && role.periods[p.ID()].Equal(period) {
+		p = mp.Merge(p)
+	}
+	role.index(p, period)
 	role.Unlock()
 	return nil
 }
 
 // Permit returns true if the role has specific permission.
-func (role *Role[T]) Permit(p Permission[T]) (ok bool) {
+// A CompositePermission is evaluated against this role only, and
+// time-bounded grants only within their periods.
+func (role *StdRole[T]) Permit(p Permission[T]) (ok bool) {
 	var zero Permission[T]
 	if p == zero {
 		return false
 	}
+	if c, isComposite := p.(CompositePermission[T]); isComposite {
+		return c.Evaluate(role.Permit)
+	}
 
 	role.RLock()
-	for _, rp := range role.permissions {
-		if rp.Match(p) {
-			ok = true
-			break
+	defer role.RUnlock()
+	if rp, found := role.permissions[p.ID()]; found && role.active(rp.ID()) && rp.Match(p) {
+		return true
+	}
+	if lp, isLayer := any(p).(LayerPermission); isLayer {
+		for _, trie := range role.layers {
+			if trie.match(strings.Split(lp.SID, lp.Sep)) {
+				return true
+			}
 		}
 	}
-	role.RUnlock()
-	return
+	for id, rp := range role.opaque {
+		if role.active(id) && rp.Match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// active returns true if the grant of `id` is untimed or within its
+// period.
+func (role *StdRole[T]) active(id T) bool {
+	period, ok := role.periods[id]
+	if !ok {
+		return true
+	}
+	now := time.Now
+	if role.clock != nil {
+		now = role.clock
+	}
+	return period.Contains(now())
 }
 
 // Revoke the specific permission.
-func (role *Role[T]) Revoke(p Permission[T]) error {
+// A MergeablePermission keeps what remains after subtracting `p`, in the
+// same period.
+func (role *StdRole[T]) Revoke(p Permission[T]) error {
 	role.Lock()
-	delete(role.permissions, p.ID())
+	if mp, ok := role.permissions[p.ID()].(MergeablePermission[T]); ok {
+		if rest := mp.Subtract(p); rest != nil {
+			role.index(rest, role.periods[p.ID()])
+			role.Unlock()
+			return nil
+		}
+	}
+	role.unindex(p.ID())
 	role.Unlock()
 	return nil
 }
 
+// index the permission `p` granted within `period`, replacing the one of
+// the same ID.
+func (role *StdRole[T]) index(p Permission[T], period Period) {
+	role.unindex(p.ID())
+	role.permissions[p.ID()] = p
+	if !period.IsZero() {
+		if role.periods == nil {
+			role.periods = make(map[T]Period)
+		}
+		role.periods[p.ID()] = period
+	}
+	switch q := any(p).(type) {
+	case StdPermission[T], MaskPermission:
+		// matched by ID only
+	case LayerPermission:
+		if !period.IsZero() {
+			role.indexOpaque(p)
+			break
+		}
+		if role.layers == nil {
+			role.layers = make(map[string]*layerTrie)
+		}
+		trie, ok := role.layers[q.Sep]
+		if !ok {
+			trie = newLayerTrie()
+			role.layers[q.Sep] = trie
+		}
+		trie.insert(strings.Split(q.SID, q.Sep))
+	default:
+		role.indexOpaque(p)
+	}
+}
+
+// indexOpaque adds `p` to the permissions scanned one by one.
+func (role *StdRole[T]) indexOpaque(p Permission[T]) {
+	if role.opaque == nil {
+		role.opaque = make(Permissions[T])
+	}
+	role.opaque[p.ID()] = p
+}
+
+// unindex the permission of `id`.
+func (role *StdRole[T]) unindex(id T) {
+	p, ok := role.permissions[id]
+	if !ok {
+		return
+	}
+	delete(role.permissions, id)
+	delete(role.opaque, id)
+	if _, timed := role.periods[id]; timed {
+		delete(role.periods, id)
+		return
+	}
+	if q, ok := any(p).(LayerPermission); ok {
+		if trie, ok := role.layers[q.Sep]; ok {
+			trie.remove(strings.Split(q.SID, q.Sep))
+		}
+	}
+}
+
 // Permissions returns all permissions into a slice.
-func (role *Role[T]) Permissions() []Permission[T] {
+func (role *StdRole[T]) Permissions() []Permission[T] {
 	role.RLock()
 	result := make([]Permission[T], 0, len(role.permissions))
 	for _, p := range role.permissions {
@@ -70,3 +239,46 @@ func (role *Role[T]) Permissions() []Permission[T] {
 	role.RUnlock()
 	return result
 }
+
+// Periods returns the periods of the time-bounded grants by permission
+// ID.
+func (role *StdRole[T]) Periods() map[T]Period {
+	role.RLock()
+	defer role.RUnlock()
+	result := make(map[T]Period, len(role.periods))
+	for id, period := range role.periods {
+		result[id] = period
+	}
+	return result
+}
+
+// SetClock replaces the clock evaluating the periods of grants.
+// A nil `clock` restores time.Now.
+func (role *StdRole[T]) SetClock(clock func() time.Time) {
+	role.Lock()
+	role.clock = clock
+	role.Unlock()
+}
+
+// GetMetadata returns a copy of the metadata of the role.
+func (role *StdRole[T]) GetMetadata() Metadata {
+	role.RLock()
+	defer role.RUnlock()
+	return role.Metadata.clone()
+}
+
+// SetMetadata replaces the metadata of the role by a copy of `m`.
+func (role *StdRole[T]) SetMetadata(m Metadata) {
+	m = m.clone()
+	role.Lock()
+	role.Metadata = m
+	role.Unlock()
+}
+
+// SetID changes the identity of the role.
+// Use RBAC.Rename for a role added to an RBAC.
+func (role *StdRole[T]) SetID(id T) {
+	role.Lock()
+	role.SID = id
+	role.Unlock()
+}
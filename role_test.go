@@ -1,13 +1,14 @@
 package gorbac
 
 import (
+	"fmt"
 	"testing"
 )
 
 func TestStdrA(t *testing.T) {
 	rA := NewRole("role-a")
-	if rA.ID != "role-a" {
-		t.Fatalf("[a] expected, but %s got", rA.ID)
+	if rA.ID() != "role-a" {
+		t.Fatalf("[a] expected, but %s got", rA.ID())
 	}
 	if err := rA.Assign(NewPermission("permission-a")); err != nil {
 		t.Fatal(err)
@@ -29,3 +30,135 @@ func TestStdrA(t *testing.T) {
 		t.Fatal("[a] should not have any permission")
 	}
 }
+
+// externalRole grants permissions listed by an external source
+// besides its own.
+type externalRole struct {
+	*StdRole[string]
+	external map[string]bool
+}
+
+func (role *externalRole) Permit(p Permission[string]) bool {
+	return role.external[p.ID()] || role.StdRole.Permit(p)
+}
+
+func TestCustomRole(t *testing.T) {
+	r := New[string]()
+	custom := &externalRole{
+		StdRole:  NewRole("custom"),
+		external: map[string]bool{"permission-x": true},
+	}
+	assert(t, custom.Assign(NewPermission("permission-a")))
+	assert(t, r.Add(custom))
+	assert(t, r.Add(NewRole("child")))
+	assert(t, r.SetParent("child", "custom"))
+	if !r.IsGranted("child", NewPermission("permission-x"), nil) {
+		t.Fatal("[permission-x] should be granted by the external source")
+	}
+	if !r.IsGranted("child", NewPermission("permission-a"), nil) {
+		t.Fatal("[permission-a] should be granted by the embedded role")
+	}
+	role, _, err := r.Get("custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := role.(*externalRole); !ok || got != custom {
+		t.Fatalf("The original role expected, but %T got", role)
+	}
+	// metadata is promoted from the embedded StdRole
+	assert(t, r.SetMetadata("custom", Metadata{Name: "Custom"}))
+	if custom.Metadata.Name != "Custom" {
+		t.Fatal("Metadata should be kept by the custom role")
+	}
+}
+
+func TestStdRoleIndex(t *testing.T) {
+	role := NewRole("indexed")
+	reports, err := NewRegexPermission(`^report-.*$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	granted := []Permission[string]{
+		NewPermission("std"),
+		NewLayerPermission("admin/users", "/"),
+		NewLayerPermission("shop::orders", "::"),
+		NewResourcePermission("document", ResourceAny, "edit"),
+		NewMaskPermission("article", ActionRead|ActionUpdate),
+		reports,
+	}
+	for _, p := range granted {
+		assert(t, role.Assign(p))
+	}
+	requests := map[Permission[string]]bool{
+		NewPermission("std"):                            true,
+		NewPermission("admin/users"):                    true,
+		NewLayerPermission("std", "/"):                  true,
+		NewLayerPermission("admin/users/42", "/"):       true,
+		NewLayerPermission("admin", "/"):                false,
+		NewLayerPermission("admin/groups", "/"):         false,
+		NewLayerPermission("shop::orders::1", "::"):     true,
+		NewLayerPermission("shop/orders/1", "/"):        true,
+		NewResourcePermission("document", "42", "edit"): true,
+		NewPermission("document:42:edit"):               true,
+		NewMaskPermission("article", ActionRead):        true,
+		NewMaskPermission("article", ActionDelete):      false,
+		NewPermission("report-daily"):                   true,
+		NewPermission("unknown"):                        false,
+	}
+	check := func() {
+		for p, expected := range requests {
+			// the index should agree with matching every permission
+			scan := false
+			for _, rp := range role.Permissions() {
+				scan = scan || rp.Match(p)
+			}
+			if scan != expected {
+				t.Fatalf("`%s` expected %v by scanning", p.ID(), expected)
+			}
+			if role.Permit(p) != expected {
+				t.Fatalf("`%s` expected %v by the index", p.ID(), expected)
+			}
+		}
+	}
+	check()
+	assert(t, role.Revoke(NewLayerPermission("admin/users", "/")))
+	assert(t, role.Revoke(reports))
+	requests[NewPermission("admin/users")] = false
+	requests[NewLayerPermission("admin/users/42", "/")] = false
+	requests[NewPermission("report-daily")] = false
+	check()
+	// replacing a permission of the same ID drops the previous index
+	assert(t, role.Assign(NewPermission("shop::orders")))
+	requests[NewLayerPermission("shop::orders::1", "::")] = false
+	requests[NewLayerPermission("shop/orders/1", "/")] = false
+	check()
+}
+
+func benchmarkRolePermit(b *testing.B, newPermission func(int) Permission[string], request Permission[string]) {
+	role := NewRole("benchmark")
+	for i := 0; i < 5000; i++ {
+		role.Assign(newPermission(i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		role.Permit(request)
+	}
+}
+
+func BenchmarkRolePermitStd(b *testing.B) {
+	benchmarkRolePermit(b, func(i int) Permission[string] {
+		return NewPermission(fmt.Sprintf("permission-%d", i))
+	}, NewPermission("permission-4999"))
+}
+
+func BenchmarkRolePermitLayer(b *testing.B) {
+	benchmarkRolePermit(b, func(i int) Permission[string] {
+		return NewLayerPermission(fmt.Sprintf("admin/section-%d", i), "/")
+	}, NewLayerPermission("admin/section-4999/page", "/"))
+}
+
+func BenchmarkRolePermitOpaque(b *testing.B) {
+	benchmarkRolePermit(b, func(i int) Permission[string] {
+		return NewResourcePermission("document", fmt.Sprintf("%d", i), "edit")
+	}, NewResourcePermission("document", "not-exist", "edit"))
+}
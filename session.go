@@ -0,0 +1,166 @@
+package gorbac
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRoleNotAuthorized occurred if a subject activates a role
+	// which is neither assigned nor inherited
+	ErrRoleNotAuthorized = errors.New("Role is not authorized")
+	// ErrRoleNotActive occurred if a role isn't active in the session
+	ErrRoleNotActive = errors.New("Role is not active")
+)
+
+// Session is a subject acting with a subset of its roles.
+// Only activated roles, and the roles they inherit from, are used to
+// check permissions. An active role stays active when it's renamed.
+type Session[T comparable] struct {
+	mutex   sync.RWMutex
+	rbac    *RBAC[T]
+	subject T
+	// active roles by their IDs when they were activated
+	active map[T]Role[T]
+}
+
+// NewSession returns a session for the `subject` without any active role.
+func (rbac *RBAC[T]) NewSession(subject T) *Session[T] {
+	return &Session[T]{
+		rbac:    rbac,
+		subject: subject,
+		active:  make(map[T]Role[T]),
+	}
+}
+
+// Subject returns the subject of the session.
+func (s *Session[T]) Subject() T {
+	return s.subject
+}
+
+// Activate the role `id` in the session.
+// The role must be assigned to the subject or inherited by an assigned
+// role, and must not break any dynamic separation of duty constraint
+// together with the roles already active. An alias activates the role
+// it resolves to.
+func (s *Session[T]) Activate(id T) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rbac.mutex.RLock()
+	defer s.rbac.mutex.RUnlock()
+	id = s.rbac.resolve(id)
+	role, ok := s.rbac.roles[id]
+	if !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	now := s.rbac.clock()
+	if _, ok := s.rbac.authorized(s.subject, now)[id]; !ok {
+		return ErrRoleNotAuthorized
+	}
+	roles := append(s.current(), id)
+	if err := checkSoD(s.rbac.dsd, ErrDSDViolation, s.subject,
+		s.rbac.ancestors(now, roles...)); err != nil {
+		return err
+	}
+	s.deactivate(id)
+	s.active[id] = role
+	return nil
+}
+
+// Deactivate the role `id`, or the role the alias `id` resolves to, in
+// the session.
+func (s *Session[T]) Deactivate(id T) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rbac.mutex.RLock()
+	defer s.rbac.mutex.RUnlock()
+	if !s.deactivate(s.rbac.resolve(id)) {
+		return ErrRoleNotActive
+	}
+	return nil
+}
+
+// deactivate the role whose ID is `id` now, and returns true if it was
+// active.
+func (s *Session[T]) deactivate(id T) bool {
+	for key, role := range s.active {
+		if role.ID() == id {
+			delete(s.active, key)
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveRoles returns the roles activated in the session by their IDs.
+func (s *Session[T]) ActiveRoles() []T {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	s.rbac.mutex.RLock()
+	defer s.rbac.mutex.RUnlock()
+	result := make([]T, 0, len(s.active))
+	for _, role := range s.active {
+		result = append(result, role.ID())
+	}
+	return result
+}
+
+// current returns the IDs of the active roles still in the RBAC.
+func (s *Session[T]) current() []T {
+	var result []T
+	for _, role := range s.active {
+		if id := role.ID(); sameRole(s.rbac.roles[id], role) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// IsGranted tests if the active roles have Permission `p` with the
+// condition `assert`, which is tested for each role. A composite
+// permission is evaluated once against all of them, so its parts may be
+// granted by different roles. Roles revoked from the subject after
+// activation are ignored.
+func (s *Session[T]) IsGranted(p Permission[T], assert AssertionFunc[T]) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	s.rbac.mutex.RLock()
+	defer s.rbac.mutex.RUnlock()
+	now := s.rbac.clock()
+	authorized := s.rbac.authorized(s.subject, now)
+	var roles []T
+	for _, id := range s.current() {
+		if _, ok := authorized[id]; !ok {
+			continue
+		}
+		if assert != nil && !assert(s.rbac, id, p) {
+			continue
+		}
+		roles = append(roles, id)
+	}
+	granted := func(q Permission[T]) bool {
+		for _, id := range roles {
+			if s.rbac.recursionCheck(id, q, now) {
+				return true
+			}
+		}
+		return false
+	}
+	if c, ok := p.(CompositePermission[T]); ok {
+		return c.Evaluate(granted)
+	}
+	return granted(p)
+}
+
+// authorized returns the roles assigned to the subject and every role
+// they inherit from at `now`.
+func (rbac *RBAC[T]) authorized(subject T, now time.Time) map[T]struct{} {
+	var roles []T
+	for id, period := range rbac.subjects[subject] {
+		if period.Contains(now) {
+			roles = append(roles, id)
+		}
+	}
+	return rbac.ancestors(now, roles...)
+}
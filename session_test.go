@@ -0,0 +1,93 @@
+package gorbac
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	r := New[string]()
+	teller, auditor, clerk := NewRole("teller"), NewRole("auditor"), NewRole("clerk")
+	pCash, pAudit, pFile := NewPermission("cash"), NewPermission("audit"), NewPermission("file")
+	assert(t, teller.Assign(pCash))
+	assert(t, auditor.Assign(pAudit))
+	assert(t, clerk.Assign(pFile))
+	assert(t, r.Add(teller))
+	assert(t, r.Add(auditor))
+	assert(t, r.Add(clerk))
+	assert(t, r.Add(NewRole("other")))
+	assert(t, r.SetParent("teller", "clerk"))
+	assert(t, r.AddDSD(SoDConstraint[string]{
+		Name:        "cash-audit",
+		Roles:       []string{"teller", "auditor"},
+		Cardinality: 2,
+	}))
+	assert(t, r.AssignRole("alice", "teller"))
+	assert(t, r.AssignRole("alice", "auditor"))
+
+	s := r.NewSession("alice")
+	if s.IsGranted(pCash, nil) {
+		t.Fatal("No permission should be granted without active roles")
+	}
+	if err := s.Activate("other"); err != ErrRoleNotAuthorized {
+		t.Fatalf("%s needed", ErrRoleNotAuthorized)
+	}
+	if err := s.Activate("not-exist"); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s needed", ErrRoleNotExist)
+	}
+	assert(t, s.Activate("teller"))
+	if !s.IsGranted(pCash, nil) || !s.IsGranted(pFile, nil) {
+		t.Fatal("[teller] and its parents should be granted")
+	}
+	if s.IsGranted(pAudit, nil) {
+		t.Fatal("[auditor] is not active")
+	}
+	if err := s.Activate("auditor"); !errors.Is(err, ErrDSDViolation) {
+		t.Fatalf("%s needed, but %v got", ErrDSDViolation, err)
+	}
+	assert(t, s.Deactivate("teller"))
+	if err := s.Deactivate("teller"); err != ErrRoleNotActive {
+		t.Fatalf("%s needed", ErrRoleNotActive)
+	}
+	assert(t, s.Activate("auditor"))
+	// an inherited role can be activated by itself
+	assert(t, s.Activate("clerk"))
+	if roles := s.ActiveRoles(); len(roles) != 2 {
+		t.Fatalf("Two roles should be active, but %v got", roles)
+	}
+	if !s.IsGranted(pAudit, nil) || !s.IsGranted(pFile, nil) || s.IsGranted(pCash, nil) {
+		t.Fatal("Only [auditor] and [clerk] should be granted")
+	}
+	assert(t, r.RevokeRole("alice", "auditor"))
+	if s.IsGranted(pAudit, nil) {
+		t.Fatal("A revoked role should not be granted")
+	}
+}
+
+func TestSessionComposite(t *testing.T) {
+	r := New[string]()
+	x, y, z := NewRole("x"), NewRole("y"), NewRole("z")
+	pRead, pDownload := NewPermission("read"), NewPermission("download")
+	assert(t, x.Assign(pRead))
+	assert(t, z.Assign(pDownload))
+	assert(t, r.Add(x))
+	assert(t, r.Add(y))
+	assert(t, r.Add(z))
+	assert(t, r.SetParent("y", "z"))
+	assert(t, r.AssignRole("alice", "x"))
+	assert(t, r.AssignRole("alice", "y"))
+
+	s := r.NewSession("alice")
+	both := AllOf[string](pRead, pDownload)
+	assert(t, s.Activate("x"))
+	if s.IsGranted(both, nil) {
+		t.Fatal("[download] is not granted by an active role")
+	}
+	assert(t, s.Activate("y"))
+	if !s.IsGranted(both, nil) {
+		t.Fatal("[read] and [download] of the active roles should be granted together")
+	}
+	if s.IsGranted(AllOf[string](pRead, NewPermission("other")), nil) {
+		t.Fatal("[other] is not granted")
+	}
+}
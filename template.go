@@ -0,0 +1,454 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrTemplateNotExist occurred if a template can't be found
+	ErrTemplateNotExist = errors.New("Template does not exist")
+	// ErrInvalidTemplate occurred if a template or its arguments are
+	// malformed
+	ErrInvalidTemplate = errors.New("Invalid template")
+)
+
+// placeholder matches a parameter in a template, e.g. "{project}".
+// Other braces, e.g. repetitions of a regular expression, are kept.
+var placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Template is a parameterised role, e.g. `project-editor(project)`.
+// Every "{param}" in the ID, the permissions, the parents and the
+// metadata is replaced by the argument of the parameter when the
+// template is instantiated:
+//
+//	gorbac.Template{
+//		Name:        "project-editor",
+//		Params:      []string{"project"},
+//		ID:          "project-{project}-editor",
+//		Permissions: []gorbac.Permission[string]{
+//			gorbac.NewLayerPermission("projects/{project}/edit", "/"),
+//		},
+//		Parents: []string{"project-{project}-viewer"},
+//	}
+//
+// Arguments are quoted in the patterns of RegexPermissions.
+type Template struct {
+	Name   string
+	Params []string
+	// ID is the pattern of the IDs of the instances, it has to use
+	// every parameter
+	ID          string
+	Permissions []Permission[string]
+	Parents     []string
+	Metadata    Metadata
+}
+
+func (t Template) String() string {
+	return t.Name + "(" + strings.Join(t.Params, ", ") + ")"
+}
+
+// check tests that the template uses only its parameters, and its ID
+// uses all of them.
+func (t Template) check() error {
+	if t.Name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidTemplate)
+	}
+	params := make(map[string]struct{}, len(t.Params))
+	for _, param := range t.Params {
+		if !placeholder.MatchString("{" + param + "}") {
+			return fmt.Errorf("%w: %s: parameter %q", ErrInvalidTemplate, t, param)
+		}
+		if _, ok := params[param]; ok {
+			return fmt.Errorf("%w: %s: duplicate parameter %q", ErrInvalidTemplate, t, param)
+		}
+		params[param] = empty
+	}
+	patterns := append([]string{t.ID, t.Metadata.Name, t.Metadata.Description}, t.Parents...)
+	patterns = append(patterns, t.Metadata.Tags...)
+	for _, value := range t.Metadata.Attributes {
+		patterns = append(patterns, value)
+	}
+	for _, p := range t.Permissions {
+		patterns = append(patterns, p.ID())
+	}
+	for _, pattern := range patterns {
+		for _, m := range placeholder.FindAllStringSubmatch(pattern, -1) {
+			if _, ok := params[m[1]]; !ok {
+				return fmt.Errorf("%w: %s: unknown parameter %q in %q", ErrInvalidTemplate, t, m[1], pattern)
+			}
+		}
+	}
+	for _, param := range t.Params {
+		if !strings.Contains(t.ID, "{"+param+"}") {
+			return fmt.Errorf("%w: %s: parameter %q not in the ID %q", ErrInvalidTemplate, t, param, t.ID)
+		}
+	}
+	return nil
+}
+
+// instance is a template expanded by its arguments.
+type instance struct {
+	id          string
+	permissions []Permission[string]
+	parents     []string
+	metadata    Metadata
+}
+
+// expand replaces the parameters of the template by `args`.
+func (t Template) expand(args map[string]string) (*instance, error) {
+	if len(args) != len(t.Params) {
+		return nil, fmt.Errorf("%w: %s: %d arguments expected, but %d got", ErrInvalidTemplate, t, len(t.Params), len(args))
+	}
+	for _, param := range t.Params {
+		if args[param] == "" {
+			return nil, fmt.Errorf("%w: %s: argument %q expected", ErrInvalidTemplate, t, param)
+		}
+	}
+	replace := func(s string) string {
+		return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+			return args[m[1:len(m)-1]]
+		})
+	}
+	in := &instance{id: replace(t.ID)}
+	for _, p := range t.Permissions {
+		var q Permission[string]
+		var err error
+		switch v := p.(type) {
+		case StdPermission[string]:
+			q = NewPermission(replace(v.SID))
+		case LayerPermission:
+			q = NewLayerPermission(replace(v.SID), v.Sep)
+		case ResourcePermission:
+			q = NewResourcePermission(replace(v.Type), replace(v.Resource), replace(v.Action))
+		case MaskPermission:
+			q = NewMaskPermission(replace(v.SID), v.Actions)
+		case RegexPermission:
+			q, err = NewRegexPermission(placeholder.ReplaceAllStringFunc(v.ID(), func(m string) string {
+				return regexp.QuoteMeta(args[m[1:len(m)-1]])
+			}))
+		default:
+			if placeholder.MatchString(p.ID()) {
+				err = fmt.Errorf("%w: %T can't be parameterised", ErrUnsupportedPermission, p)
+			}
+			q = p
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t, err)
+		}
+		in.permissions = append(in.permissions, q)
+	}
+	for _, parent := range t.Parents {
+		in.parents = append(in.parents, replace(parent))
+	}
+	m := t.Metadata
+	in.metadata = Metadata{Name: replace(m.Name), Description: replace(m.Description)}
+	for _, tag := range m.Tags {
+		in.metadata.Tags = append(in.metadata.Tags, replace(tag))
+	}
+	for key, value := range m.Attributes {
+		if in.metadata.Attributes == nil {
+			in.metadata.Attributes = make(map[string]string, len(m.Attributes))
+		}
+		in.metadata.Attributes[key] = replace(value)
+	}
+	return in, nil
+}
+
+// Templates instantiates templates into an RBAC, and keeps track of the
+// instances, so updating a template updates every instance of it. The
+// instances are tracked by the role, not its ID, so a renamed instance
+// is still updated, and a role added later by the old ID isn't.
+type Templates struct {
+	mutex     sync.Mutex
+	rbac      *RBAC[string]
+	templates map[string]Template
+	// instances are the instances of each template
+	instances map[string]map[*StdRole[string]]*tracked
+}
+
+// tracked is an instance of a template: its arguments and what the
+// template added to it.
+type tracked struct {
+	args map[string]string
+	// permissions are the grants added, e.g. only the actions of a mask
+	// the role didn't have
+	permissions []Permission[string]
+	// parents are the edges added
+	parents []string
+}
+
+// NewTemplates returns templates instantiated into `rbac`.
+func NewTemplates(rbac *RBAC[string]) *Templates {
+	return &Templates{
+		rbac:      rbac,
+		templates: make(map[string]Template),
+		instances: make(map[string]map[*StdRole[string]]*tracked),
+	}
+}
+
+// Define the template `t`. Defining a template again updates every
+// instance of it: the permissions and parents the previous definition
+// added are replaced by the new ones, and so is the metadata. The others,
+// e.g. assigned to an instance directly, are kept, including the ones
+// an instance already had when the template added them. The parameters
+// and the ID of a template with instances can't change. Either every
+// instance is updated or none.
+func (ts *Templates) Define(t Template) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	old, ok := ts.templates[t.Name]
+	if !ok {
+		ts.templates[t.Name] = t
+		return nil
+	}
+	ts.rbac.mutex.Lock()
+	defer ts.rbac.mutex.Unlock()
+	instances := ts.live(t.Name)
+	for role := range ts.instances[t.Name] {
+		if instances[role.ID()] != role {
+			// removed from the RBAC
+			delete(ts.instances[t.Name], role)
+		}
+	}
+	if len(instances) > 0 && (old.ID != t.ID || !sameParams(old.Params, t.Params)) {
+		return fmt.Errorf("%w: %s has instances, its parameters and ID can't change", ErrInvalidTemplate, old)
+	}
+	ids := keys(instances)
+	sort.Strings(ids)
+	var undo []func()
+	updated := make(map[*StdRole[string]]*tracked, len(ids))
+	for _, id := range ids {
+		role := instances[id]
+		restore, tr, err := ts.update(t, role, ts.instances[t.Name][role])
+		if err != nil {
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		undo = append(undo, restore)
+		updated[role] = tr
+	}
+	for role, tr := range updated {
+		ts.instances[t.Name][role] = tr
+	}
+	ts.templates[t.Name] = t
+	return nil
+}
+
+// live returns the instances of the template `name` still in the RBAC by
+// their IDs.
+func (ts *Templates) live(name string) map[string]*StdRole[string] {
+	result := make(map[string]*StdRole[string], len(ts.instances[name]))
+	for role := range ts.instances[name] {
+		if r, ok := ts.rbac.roles[role.ID()]; ok && r == Role[string](role) {
+			result[role.ID()] = role
+		}
+	}
+	return result
+}
+
+// update replaces what the template added to the instance `role`, as
+// tracked by `from`, by the expansion of `t`, and returns the function
+// restoring it and the new tracking.
+func (ts *Templates) update(t Template, role *StdRole[string], from *tracked) (func(), *tracked, error) {
+	to, err := t.expand(from.args)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, parent := range to.parents {
+		if _, ok := ts.rbac.roles[parent]; !ok {
+			return nil, nil, &RoleNotFoundError[string]{parent}
+		}
+	}
+	id := role.ID()
+	permissions, periods := role.Permissions(), role.Periods()
+	parents := make(map[string]Period, len(ts.rbac.parents[id]))
+	for parent, period := range ts.rbac.parents[id] {
+		parents[parent] = period
+	}
+	metadata := metadataOf(role)
+	restore := func() {
+		for _, p := range role.Permissions() {
+			role.Revoke(p)
+		}
+		for _, p := range permissions {
+			role.AssignWithin(p, periods[p.ID()])
+		}
+		ts.rbac.parents[id] = parents
+		role.SetMetadata(metadata)
+	}
+	for _, p := range from.permissions {
+		role.Revoke(p)
+	}
+	for _, parent := range from.parents {
+		delete(ts.rbac.parents[id], parent)
+	}
+	tr := &tracked{args: from.args}
+	if err := ts.apply(role, to, tr); err != nil {
+		restore()
+		return nil, nil, err
+	}
+	role.SetMetadata(to.metadata)
+	return restore, tr, nil
+}
+
+// apply assigns the permissions and binds the parents of the expansion
+// `in` to the role, tracking in `tr` only what the role didn't have.
+func (ts *Templates) apply(role *StdRole[string], in *instance, tr *tracked) error {
+	for _, p := range in.permissions {
+		added := contribution(role, p)
+		if err := role.Assign(p); err != nil {
+			return err
+		}
+		if added != nil {
+			tr.permissions = append(tr.permissions, added)
+		}
+	}
+	id := role.ID()
+	var parents []string
+	for _, parent := range in.parents {
+		if _, ok := ts.rbac.parents[id][parent]; !ok && indexOf(parents, parent) < 0 {
+			parents = append(parents, parent)
+		}
+	}
+	if err := ts.rbac.bindParents(id, parents, Period{}); err != nil {
+		return err
+	}
+	tr.parents = parents
+	return nil
+}
+
+// contribution returns the part of the permission `p` the role doesn't
+// have unbounded, or nil if it has all of it.
+func contribution(role *StdRole[string], p Permission[string]) Permission[string] {
+	for _, q := range role.Permissions() {
+		if q.ID() != p.ID() {
+			continue
+		}
+		if !role.Periods()[q.ID()].IsZero() {
+			// replaced by the unbounded grant
+			return p
+		}
+		if mp, ok := p.(MergeablePermission[string]); ok {
+			if rest := mp.Subtract(q); rest != nil {
+				return rest
+			}
+			return nil
+		}
+		if reflect.DeepEqual(p, q) {
+			return nil
+		}
+		return p
+	}
+	return p
+}
+
+// sameParams tests if two lists have the same parameters.
+func sameParams(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, param := range a {
+		if indexOf(b, param) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the template `name`.
+func (ts *Templates) Get(name string) (Template, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	t, ok := ts.templates[name]
+	if !ok {
+		return Template{}, fmt.Errorf("%w: %s", ErrTemplateNotExist, name)
+	}
+	return t, nil
+}
+
+// Instantiate the template `name` by the arguments of its parameters,
+// e.g. `{"project": "42"}`, and returns the ID of the new role. The
+// parents have to exist already.
+func (ts *Templates) Instantiate(name string, args map[string]string) (string, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	t, ok := ts.templates[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrTemplateNotExist, name)
+	}
+	in, err := t.expand(args)
+	if err != nil {
+		return "", err
+	}
+	role := NewRole(in.id)
+	role.Metadata = in.metadata
+	ts.rbac.mutex.Lock()
+	defer ts.rbac.mutex.Unlock()
+	if _, ok := ts.rbac.roles[in.id]; ok {
+		return "", &RoleExistError[string]{in.id}
+	}
+	for _, parent := range in.parents {
+		if _, ok := ts.rbac.roles[parent]; !ok {
+			return "", &RoleNotFoundError[string]{parent}
+		}
+	}
+	copied := make(map[string]string, len(args))
+	for param, arg := range args {
+		copied[param] = arg
+	}
+	tr := &tracked{args: copied}
+	ts.rbac.add(role)
+	if err := ts.apply(role, in, tr); err != nil {
+		delete(ts.rbac.roles, in.id)
+		delete(ts.rbac.parents, in.id)
+		return "", err
+	}
+	if ts.instances[name] == nil {
+		ts.instances[name] = make(map[*StdRole[string]]*tracked)
+	}
+	ts.instances[name][role] = tr
+	return in.id, nil
+}
+
+// InstanceOf returns the template and the arguments the role `id` is
+// instantiated from, if it's still in the RBAC.
+func (ts *Templates) InstanceOf(id string) (name string, args map[string]string, ok bool) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.rbac.mutex.RLock()
+	defer ts.rbac.mutex.RUnlock()
+	for name := range ts.instances {
+		if role, ok := ts.live(name)[id]; ok {
+			args := ts.instances[name][role].args
+			result := make(map[string]string, len(args))
+			for param, arg := range args {
+				result[param] = arg
+			}
+			return name, result, true
+		}
+	}
+	return "", nil, false
+}
+
+// Instances returns the roles instantiated from the template `name`,
+// which are still in the RBAC.
+func (ts *Templates) Instances(name string) []string {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.rbac.mutex.RLock()
+	defer ts.rbac.mutex.RUnlock()
+	result := keys(ts.live(name))
+	sort.Strings(result)
+	return result
+}
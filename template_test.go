@@ -0,0 +1,181 @@
+package gorbac
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func projectEditor(t *testing.T, extra ...Permission[string]) Template {
+	reports, err := NewRegexPermission(`^projects/{project}/reports/\d{4}$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Template{
+		Name:   "project-editor",
+		Params: []string{"project"},
+		ID:     "project-{project}-editor",
+		Permissions: append([]Permission[string]{
+			NewLayerPermission("projects/{project}/edit", "/"),
+			NewResourcePermission("project", "{project}", "comment"),
+			reports,
+		}, extra...),
+		Parents:  []string{"viewer"},
+		Metadata: Metadata{Name: "Editor of {project}", Attributes: map[string]string{"project": "{project}"}},
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(NewRole("viewer")))
+	assert(t, r.Add(NewRole("auditor")))
+	ts := NewTemplates(r)
+	assert(t, ts.Define(projectEditor(t)))
+	id, err := ts.Instantiate("project-editor", map[string]string{"project": "4.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "project-4.2-editor" {
+		t.Fatalf("project-4.2-editor expected, but %s got", id)
+	}
+	_, err = ts.Instantiate("project-editor", map[string]string{"project": "7"})
+	assert(t, err)
+	assert(t, roleOf(t, r, "project-7-editor").Assign(NewPermission("publish")))
+	for _, c := range []struct {
+		p       Permission[string]
+		granted bool
+	}{
+		{NewLayerPermission("projects/4.2/edit/docs", "/"), true},
+		{NewLayerPermission("projects/7/edit", "/"), false},
+		{NewResourcePermission("project", "4.2", "comment"), true},
+		{NewPermission("projects/4.2/reports/2024"), true},
+		{NewPermission("projects/452/reports/2024"), false},
+	} {
+		if r.IsGranted(id, c.p, nil) != c.granted {
+			t.Fatalf("[%s] granted expected to be %t", c.p.ID(), c.granted)
+		}
+	}
+	m, err := r.GetMetadata(id)
+	assert(t, err)
+	if m.Name != "Editor of 4.2" || m.Attributes["project"] != "4.2" {
+		t.Fatalf("The metadata expected to be expanded, but %+v got", m)
+	}
+	name, args, ok := ts.InstanceOf(id)
+	if !ok || name != "project-editor" || !reflect.DeepEqual(args, map[string]string{"project": "4.2"}) {
+		t.Fatalf("project-editor(4.2) expected, but %s%v got", name, args)
+	}
+
+	// updating the template updates every instance
+	updated := projectEditor(t, NewPermission("projects/{project}/publish"))
+	updated.Permissions = updated.Permissions[1:]
+	updated.Parents = []string{"auditor"}
+	assert(t, ts.Define(updated))
+	if r.IsGranted("project-7-editor", NewLayerPermission("projects/7/edit", "/"), nil) {
+		t.Fatal("[projects/7/edit] should be revoked by the update")
+	}
+	if !r.IsGranted("project-7-editor", NewPermission("projects/7/publish"), nil) {
+		t.Fatal("[projects/7/publish] should be granted by the update")
+	}
+	if !r.IsGranted("project-7-editor", NewPermission("publish"), nil) {
+		t.Fatal("[publish] assigned directly should be kept")
+	}
+	if parents, _ := r.GetParents("project-4.2-editor"); !reflect.DeepEqual(parents, []string{"auditor"}) {
+		t.Fatalf("[auditor] expected, but %v got", parents)
+	}
+	if instances := ts.Instances("project-editor"); !reflect.DeepEqual(instances, []string{"project-4.2-editor", "project-7-editor"}) {
+		t.Fatalf("Two instances expected, but %v got", instances)
+	}
+
+	// an update failing for any instance changes none
+	assert(t, r.SetLimit("project-7-editor", Limit{Parents: 1}))
+	broken := updated
+	broken.Parents = []string{"auditor", "viewer"}
+	if err := ts.Define(broken); !errors.Is(err, ErrTooManyParents) {
+		t.Fatalf("%s expected, but %v got", ErrTooManyParents, err)
+	}
+	if parents, _ := r.GetParents("project-4.2-editor"); !reflect.DeepEqual(parents, []string{"auditor"}) {
+		t.Fatalf("[auditor] expected, but %v got", parents)
+	}
+}
+
+func TestTemplateDirectGrants(t *testing.T) {
+	r := New[string]()
+	assert(t, r.Add(NewRole("viewer")))
+	assert(t, r.Add(NewRole("auditor")))
+	ts := NewTemplates(r)
+	v1 := Template{
+		Name:        "reviewer",
+		Params:      []string{"doc"},
+		ID:          "{doc}-reviewer",
+		Permissions: []Permission[string]{NewMaskPermission("{doc}", ActionRead)},
+		Parents:     []string{"viewer"},
+	}
+	assert(t, ts.Define(v1))
+	id, err := ts.Instantiate("reviewer", map[string]string{"doc": "spec"})
+	assert(t, err)
+	// granted directly, partly the same as the next definition
+	role := roleOf(t, r, id)
+	assert(t, role.Assign(NewPermission("comment")))
+	assert(t, role.Assign(NewMaskPermission("spec", ActionUpdate)))
+	assert(t, r.SetParent(id, "auditor"))
+
+	v2 := v1
+	v2.Permissions = []Permission[string]{NewPermission("comment"), NewMaskPermission("spec", ActionRead|ActionUpdate)}
+	v2.Parents = []string{"viewer", "auditor"}
+	assert(t, ts.Define(v2))
+	v3 := v1
+	v3.Permissions = nil
+	v3.Parents = nil
+	assert(t, ts.Define(v3))
+
+	if !r.IsGranted(id, NewPermission("comment"), nil) {
+		t.Fatal("[comment] granted directly should be kept")
+	}
+	if !r.IsGranted(id, NewMaskPermission("spec", ActionUpdate), nil) {
+		t.Fatal("[spec update] granted directly should be kept")
+	}
+	if r.IsGranted(id, NewMaskPermission("spec", ActionRead), nil) {
+		t.Fatal("[spec read] of the template should be revoked")
+	}
+	parents, err := r.GetParents(id)
+	assert(t, err)
+	if !equalIDs(parents, []string{"auditor"}) {
+		t.Fatalf("[auditor] bound directly expected, but %v got", parents)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	r := New[string]()
+	ts := NewTemplates(r)
+	for _, tmpl := range []Template{
+		{Params: []string{"p"}, ID: "{p}"},
+		{Name: "a", Params: []string{"p"}, ID: "a"},
+		{Name: "a", Params: []string{"p", "p"}, ID: "{p}"},
+		{Name: "a", Params: []string{"p"}, ID: "{p}", Parents: []string{"{q}"}},
+	} {
+		if err := ts.Define(tmpl); !errors.Is(err, ErrInvalidTemplate) {
+			t.Fatalf("%s expected for %s, but %v got", ErrInvalidTemplate, tmpl, err)
+		}
+	}
+	if _, err := ts.Instantiate("missing", nil); !errors.Is(err, ErrTemplateNotExist) {
+		t.Fatalf("%s expected, but %v got", ErrTemplateNotExist, err)
+	}
+	assert(t, ts.Define(projectEditor(t)))
+	if _, err := ts.Instantiate("project-editor", map[string]string{"project": "1"}); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleNotExist, err)
+	}
+	if _, err := ts.Instantiate("project-editor", map[string]string{"other": "1"}); !errors.Is(err, ErrInvalidTemplate) {
+		t.Fatalf("%s expected, but %v got", ErrInvalidTemplate, err)
+	}
+	assert(t, r.Add(NewRole("viewer")))
+	_, err := ts.Instantiate("project-editor", map[string]string{"project": "1"})
+	assert(t, err)
+	if _, err := ts.Instantiate("project-editor", map[string]string{"project": "1"}); !errors.Is(err, ErrRoleExist) {
+		t.Fatalf("%s expected, but %v got", ErrRoleExist, err)
+	}
+	renamed := projectEditor(t)
+	renamed.ID = "editor-{project}"
+	if err := ts.Define(renamed); !errors.Is(err, ErrInvalidTemplate) {
+		t.Fatalf("%s expected, but %v got", ErrInvalidTemplate, err)
+	}
+}
@@ -0,0 +1,189 @@
+package gorbac
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTimedGrantNotSupported occurred if a role doesn't implement
+	// TimedRole
+	ErrTimedGrantNotSupported = errors.New("Role does not support time-bounded grants")
+)
+
+// Period bounds the validity of a grant, an assignment or an inheritance
+// edge. A zero NotBefore or NotAfter leaves that side unbounded.
+type Period struct {
+	NotBefore time.Time `json:"not_before,omitzero"`
+	NotAfter  time.Time `json:"not_after,omitzero"`
+}
+
+// Contains returns true if `t` is within the period.
+func (p Period) Contains(t time.Time) bool {
+	if !p.NotBefore.IsZero() && t.Before(p.NotBefore) {
+		return false
+	}
+	if !p.NotAfter.IsZero() && t.After(p.NotAfter) {
+		return false
+	}
+	return true
+}
+
+// Expired returns true if the period is over at `t`.
+func (p Period) Expired(t time.Time) bool {
+	return !p.NotAfter.IsZero() && t.After(p.NotAfter)
+}
+
+// Equal returns true if both periods have the same bounds.
+func (p Period) Equal(q Period) bool {
+	return p.NotBefore.Equal(q.NotBefore) && p.NotAfter.Equal(q.NotAfter)
+}
+
+// IsZero returns true if the period is unbounded.
+func (p Period) IsZero() bool {
+	return p.NotBefore.IsZero() && p.NotAfter.IsZero()
+}
+
+// Kinds of time-bounded entries
+const (
+	// ExpiryGrant is a permission granted to a role
+	ExpiryGrant = "grant"
+	// ExpiryAssignment is a role assigned to a subject
+	ExpiryAssignment = "assignment"
+	// ExpiryParent is an inheritance edge between roles
+	ExpiryParent = "parent"
+)
+
+// Expiry describes a time-bounded entry, e.g. one whose period is over.
+type Expiry[T comparable] struct {
+	// Kind is one of ExpiryGrant, ExpiryAssignment and ExpiryParent
+	Kind string `json:"kind"`
+	// ID is the role, or the subject for assignments
+	ID T `json:"id"`
+	// Target is the permission, the assigned role or the parent
+	Target T      `json:"target"`
+	Period Period `json:"period"`
+}
+
+// SetClock replaces the clock used to evaluate periods.
+// A nil `clock` restores time.Now.
+func (rbac *RBAC[T]) SetClock(clock func() time.Time) {
+	rbac.mutex.Lock()
+	if clock == nil {
+		clock = time.Now
+	}
+	rbac.clock = clock
+	rbac.mutex.Unlock()
+}
+
+// AssignWithin assigns the permission `p` to the role `id`, valid only
+// within `period`. The period is kept by the role as part of the grant,
+// so the role must implement TimedRole. Assigning a permission again
+// replaces its period, and revoking it drops the period.
+func (rbac *RBAC[T]) AssignWithin(id T, p Permission[T], period Period) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	role, ok := rbac.roles[id]
+	if !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	tr, ok := role.(TimedRole[T])
+	if !ok {
+		return ErrTimedGrantNotSupported
+	}
+	return tr.AssignWithin(p, period)
+}
+
+// SetParentWithin binds the `parent` to the role `id`, valid only
+// within `period`.
+func (rbac *RBAC[T]) SetParentWithin(id T, parent T, period Period) error {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	if _, ok := rbac.roles[id]; !ok {
+		return &RoleNotFoundError[T]{id}
+	}
+	if _, ok := rbac.roles[parent]; !ok {
+		return &RoleNotFoundError[T]{parent}
+	}
+	return rbac.bindParents(id, []T{parent}, period)
+}
+
+// Expired lists every grant, assignment and inheritance edge whose
+// period is over.
+func (rbac *RBAC[T]) Expired() []Expiry[T] {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	return rbac.expired(rbac.clock())
+}
+
+// Purge removes every grant, assignment and inheritance edge whose
+// period is over, and returns them.
+func (rbac *RBAC[T]) Purge() []Expiry[T] {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+	result := rbac.expired(rbac.clock())
+	for _, e := range result {
+		switch e.Kind {
+		case ExpiryGrant:
+			if role, ok := rbac.roles[e.ID]; ok {
+				for _, p := range role.Permissions() {
+					if p.ID() == e.Target {
+						role.Revoke(p)
+					}
+				}
+			}
+		case ExpiryAssignment:
+			delete(rbac.subjects[e.ID], e.Target)
+		case ExpiryParent:
+			delete(rbac.parents[e.ID], e.Target)
+		}
+	}
+	return result
+}
+
+func (rbac *RBAC[T]) expired(now time.Time) []Expiry[T] {
+	return rbac.periods(func(p Period) bool { return p.Expired(now) })
+}
+
+// periods lists every time-bounded entry whose period satisfies `f`.
+func (rbac *RBAC[T]) periods(f func(Period) bool) (result []Expiry[T]) {
+	collect := func(kind string, entries map[T]map[T]Period) {
+		for id, targets := range entries {
+			for target, period := range targets {
+				if !period.IsZero() && f(period) {
+					result = append(result, Expiry[T]{
+						Kind:   kind,
+						ID:     id,
+						Target: target,
+						Period: period,
+					})
+				}
+			}
+		}
+	}
+	grants := make(map[T]map[T]Period)
+	for id, role := range rbac.roles {
+		if periods := grantPeriods(role); len(periods) > 0 {
+			grants[id] = periods
+		}
+	}
+	collect(ExpiryGrant, grants)
+	collect(ExpiryAssignment, rbac.subjects)
+	collect(ExpiryParent, rbac.parents)
+	return
+}
+
+// grantPeriods returns the periods of the time-bounded grants of a role
+// by permission ID.
+func grantPeriods[T comparable](role Role[T]) map[T]Period {
+	if tr, ok := role.(TimedRole[T]); ok {
+		return tr.Periods()
+	}
+	return nil
+}
+
+// now returns the time of the clock, for the roles to evaluate the
+// periods of their grants.
+func (rbac *RBAC[T]) now() time.Time {
+	return rbac.clock()
+}
@@ -0,0 +1,145 @@
+package gorbac
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPeriod(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var unbounded Period
+	if !unbounded.IsZero() || !unbounded.Contains(now) || unbounded.Expired(now) {
+		t.Fatal("A zero period should be unbounded")
+	}
+	p := Period{NotBefore: now, NotAfter: now.Add(time.Hour)}
+	if p.Contains(now.Add(-time.Second)) || !p.Contains(now) || !p.Contains(now.Add(time.Hour)) {
+		t.Fatal("Bounds of a period should be inclusive")
+	}
+	if p.Expired(now.Add(time.Hour)) || !p.Expired(now.Add(time.Hour+time.Second)) {
+		t.Fatal("A period should expire after NotAfter")
+	}
+}
+
+func TestTemporal(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New[string]()
+	r.SetClock(func() time.Time { return now })
+	contractor, oncall, ops := NewRole("contractor"), NewRole("on-call"), NewRole("ops")
+	pDeploy, pRead, pPage := NewPermission("deploy"), NewPermission("read"), NewPermission("page")
+	assert(t, contractor.Assign(pRead))
+	assert(t, ops.Assign(pDeploy))
+	assert(t, r.Add(contractor))
+	assert(t, r.Add(oncall))
+	assert(t, r.Add(ops))
+	week := Period{NotBefore: now, NotAfter: now.Add(7 * 24 * time.Hour)}
+	assert(t, r.AssignWithin("contractor", pPage, week))
+	assert(t, r.SetParentWithin("on-call", "ops", Period{NotAfter: now.Add(time.Hour)}))
+	assert(t, r.AssignRoleWithin("bob", "on-call", week))
+	if err := r.AssignWithin("not-exist", pPage, week); !errors.Is(err, ErrRoleNotExist) {
+		t.Fatalf("%s needed", ErrRoleNotExist)
+	}
+
+	if !r.IsGranted("contractor", pPage, nil) || !r.IsGranted("contractor", pRead, nil) {
+		t.Fatal("[contractor] should be granted within the period")
+	}
+	if !r.IsGranted("on-call", pDeploy, nil) {
+		t.Fatal("[on-call] should inherit from [ops] within the period")
+	}
+	s := r.NewSession("bob")
+	assert(t, s.Activate("ops"))
+	if len(r.Expired()) != 0 {
+		t.Fatal("Nothing should be expired")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if r.IsGranted("on-call", pDeploy, nil) {
+		t.Fatal("[on-call] should not inherit from [ops] after the period")
+	}
+	if s.IsGranted(pDeploy, nil) {
+		t.Fatal("[ops] is no longer authorized for bob")
+	}
+
+	now = now.Add(7 * 24 * time.Hour)
+	if r.IsGranted("contractor", pPage, nil) {
+		t.Fatal("[contractor] should not be granted after the period")
+	}
+	if !r.IsGranted("contractor", pRead, nil) {
+		t.Fatal("Unbounded grants should not expire")
+	}
+	if expired := r.Expired(); len(expired) != 3 {
+		t.Fatalf("Three entries should be expired, but %v got", expired)
+	}
+	purged := r.Purge()
+	kinds := make(map[string]int)
+	for _, e := range purged {
+		kinds[e.Kind]++
+	}
+	if kinds[ExpiryGrant] != 1 || kinds[ExpiryAssignment] != 1 || kinds[ExpiryParent] != 1 {
+		t.Fatalf("Unexpected purged entries: %v", purged)
+	}
+	if len(contractor.Permissions()) != 1 {
+		t.Fatal("The expired grant should be revoked")
+	}
+	if len(r.GetRoles("bob")) != 0 {
+		t.Fatal("The expired assignment should be removed")
+	}
+	if len(r.Expired()) != 0 {
+		t.Fatal("Nothing should be expired after purging")
+	}
+}
+
+func TestTimedGrantInRole(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New[string]()
+	r.SetClock(func() time.Time { return now })
+	custom := &externalRole{
+		StdRole:  NewRole("custom"),
+		external: map[string]bool{"permission-x": true},
+	}
+	assert(t, r.Add(custom))
+	over := Period{NotAfter: now.Add(-time.Hour)}
+	pPage := NewPermission("page")
+	assert(t, r.AssignWithin("custom", pPage, over))
+	if !r.IsGranted("custom", NewPermission("permission-x"), nil) {
+		t.Fatal("The Permit of a custom role should be kept with timed grants")
+	}
+	if r.IsGranted("custom", pPage, nil) {
+		t.Fatal("[page] should not be granted after the period")
+	}
+	// the period is part of the grant
+	assert(t, custom.Revoke(pPage))
+	assert(t, custom.Assign(pPage))
+	if !r.IsGranted("custom", pPage, nil) {
+		t.Fatal("[page] assigned again should be granted without a period")
+	}
+	if len(r.Expired()) != 0 {
+		t.Fatalf("Nothing should be expired, but %v got", r.Expired())
+	}
+	// a mask assigned without a period replaces a timed one
+	assert(t, r.AssignWithin("custom", NewMaskPermission("comment", ActionRead), over))
+	assert(t, custom.Assign(NewMaskPermission("comment", ActionUpdate)))
+	if !r.IsGranted("custom", NewMaskPermission("comment", ActionUpdate), nil) {
+		t.Fatal("[comment] should be granted without a period")
+	}
+	if r.IsGranted("custom", NewMaskPermission("comment", ActionRead), nil) {
+		t.Fatal("[comment] should not keep the actions of the expired grant")
+	}
+	// the same period in another location merges masks
+	week := Period{NotAfter: now.Add(7 * 24 * time.Hour)}
+	assert(t, r.AssignWithin("custom", NewMaskPermission("report", ActionRead), week))
+	local := Period{NotAfter: week.NotAfter.In(time.FixedZone("UTC+8", 8*60*60))}
+	assert(t, r.AssignWithin("custom", NewMaskPermission("report", ActionUpdate), local))
+	if !r.IsGranted("custom", NewMaskPermission("report", ActionRead|ActionUpdate), nil) {
+		t.Fatal("[report] should be merged within the same period")
+	}
+	// a timed layer isn't matched by the trie out of its period
+	assert(t, r.AssignWithin("custom", NewLayerPermission("articles", "/"), over))
+	if r.IsGranted("custom", NewLayerPermission("articles/edit", "/"), nil) {
+		t.Fatal("[articles] should not be granted after the period")
+	}
+	assert(t, custom.Assign(NewLayerPermission("articles", "/")))
+	if !r.IsGranted("custom", NewLayerPermission("articles/edit", "/"), nil) {
+		t.Fatal("[articles] should be granted without a period")
+	}
+}
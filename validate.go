@@ -0,0 +1,270 @@
+package gorbac
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Severity of a finding
+type Severity int
+
+// Severities in ascending order
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+var severities = []string{"info", "warning", "error"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severities) {
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+	return severities[s]
+}
+
+// MarshalText encodes the severity by its name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes the severity from its name
+func (s *Severity) UnmarshalText(text []byte) error {
+	for i, name := range severities {
+		if name == string(text) {
+			*s = Severity(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown severity %q", text)
+}
+
+// Codes of the built-in rules
+const (
+	CodeEmptyRole      = "empty-role"
+	CodeRedundantEdge  = "redundant-edge"
+	CodeRedundantGrant = "redundant-grant"
+	CodeDanglingParent = "dangling-parent"
+	CodeCircle         = "circle"
+	CodeConstraint     = "constraint"
+	CodeExpired        = "expired"
+)
+
+// Finding is a problem found in a policy.
+type Finding[T comparable] struct {
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	// Role is the role the finding is about
+	Role T `json:"role"`
+	// Related are other roles or permissions involved
+	Related []T `json:"related,omitempty"`
+	// Message describes the finding for people, its IDs are rendered by
+	// fmt.Sprint rather than an IDCodec
+	Message string `json:"message"`
+}
+
+// Rule checks a policy for one kind of problem.
+// Check is called without holding the lock of RBAC, so it can use any
+// exported method or helper.
+type Rule[T comparable] struct {
+	Code     string
+	Severity Severity
+	Check    func(*RBAC[T]) []Finding[T]
+}
+
+// DefaultRules returns all built-in rules:
+//
+//   - empty-role: a role without permissions and parents.
+//   - redundant-edge: a parent which is inherited through another parent.
+//   - redundant-grant: a permission which is also assigned to an ancestor.
+//   - dangling-parent: a parent which doesn't exist.
+//   - circle: a circle inheritance.
+//   - constraint: a broken separation of duty constraint or role limit.
+//   - expired: a grant, an assignment or an edge whose period is over.
+func DefaultRules[T comparable]() []Rule[T] {
+	return []Rule[T]{
+		{CodeEmptyRole, SeverityWarning, checkEmptyRole[T]},
+		{CodeRedundantEdge, SeverityWarning, checkRedundantEdge[T]},
+		{CodeRedundantGrant, SeverityInfo, checkRedundantGrant[T]},
+		{CodeDanglingParent, SeverityError, checkDanglingParent[T]},
+		{CodeCircle, SeverityError, checkCircle[T]},
+		{CodeConstraint, SeverityError, checkConstraint[T]},
+		{CodeExpired, SeverityInfo, checkExpired[T]},
+	}
+}
+
+// Validate checks the policy by `rules`, or by DefaultRules if no rule
+// is given. Findings are sorted by severity, code and role, with the
+// code and the severity of the rule reporting them.
+func Validate[T comparable](rbac *RBAC[T], rules ...Rule[T]) []Finding[T] {
+	if len(rules) == 0 {
+		rules = DefaultRules[T]()
+	}
+	var findings []Finding[T]
+	for _, rule := range rules {
+		for _, f := range rule.Check(rbac) {
+			f.Code = rule.Code
+			f.Severity = rule.Severity
+			findings = append(findings, f)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return fmt.Sprint(a.Role) < fmt.Sprint(b.Role)
+	})
+	return findings
+}
+
+// MaxSeverity returns the highest severity of `findings`,
+// or -1 if there is no finding.
+func MaxSeverity[T comparable](findings []Finding[T]) Severity {
+	max := Severity(-1)
+	for _, f := range findings {
+		if f.Severity > max {
+			max = f.Severity
+		}
+	}
+	return max
+}
+
+func checkEmptyRole[T comparable](rbac *RBAC[T]) (findings []Finding[T]) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	for id, role := range rbac.roles {
+		if len(role.Permissions()) == 0 && len(rbac.parents[id]) == 0 {
+			findings = append(findings, Finding[T]{
+				Role:    id,
+				Message: fmt.Sprintf("%v has neither permissions nor parents", id),
+			})
+		}
+	}
+	return
+}
+
+func checkRedundantEdge[T comparable](rbac *RBAC[T]) (findings []Finding[T]) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	for id, parents := range rbac.parents {
+		for parent := range parents {
+			for via := range parents {
+				if via == parent || via == id {
+					continue
+				}
+				if _, ok := rbac.ancestors(time.Time{}, via)[parent]; ok {
+					findings = append(findings, Finding[T]{
+						Role:    id,
+						Related: []T{parent, via},
+						Message: fmt.Sprintf("%v inherits from %v through %v already", id, parent, via),
+					})
+					break
+				}
+			}
+		}
+	}
+	return
+}
+
+func checkRedundantGrant[T comparable](rbac *RBAC[T]) (findings []Finding[T]) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	for id, role := range rbac.roles {
+		ancestors := rbac.ancestors(time.Time{}, keys(rbac.parents[id])...)
+		delete(ancestors, id)
+		for _, p := range role.Permissions() {
+			for aid := range ancestors {
+				ancestor, ok := rbac.roles[aid]
+				if !ok || !hasPermission(ancestor, p.ID()) {
+					continue
+				}
+				findings = append(findings, Finding[T]{
+					Role:    id,
+					Related: []T{p.ID(), aid},
+					Message: fmt.Sprintf("%v is assigned to %v and its ancestor %v", p.ID(), id, aid),
+				})
+				break
+			}
+		}
+	}
+	return
+}
+
+func hasPermission[T comparable](role Role[T], id T) bool {
+	for _, p := range role.Permissions() {
+		if p.ID() == id {
+			return true
+		}
+	}
+	return false
+}
+
+func checkDanglingParent[T comparable](rbac *RBAC[T]) (findings []Finding[T]) {
+	rbac.mutex.RLock()
+	defer rbac.mutex.RUnlock()
+	for id, parents := range rbac.parents {
+		if _, ok := rbac.roles[id]; !ok && len(parents) > 0 {
+			findings = append(findings, Finding[T]{
+				Role:    id,
+				Message: fmt.Sprintf("%v has parents but doesn't exist", id),
+			})
+		}
+		for parent := range parents {
+			if _, ok := rbac.roles[parent]; !ok {
+				findings = append(findings, Finding[T]{
+					Role:    id,
+					Related: []T{parent},
+					Message: fmt.Sprintf("%v inherits from %v which doesn't exist", id, parent),
+				})
+			}
+		}
+	}
+	return
+}
+
+func checkCircle[T comparable](rbac *RBAC[T]) []Finding[T] {
+	var cycle *CycleError[T]
+	if err := InherCircle(rbac); errors.As(err, &cycle) {
+		return []Finding[T]{{
+			Role:    cycle.Path[0],
+			Related: cycle.Path,
+			Message: err.Error(),
+		}}
+	}
+	return nil
+}
+
+func checkConstraint[T comparable](rbac *RBAC[T]) (findings []Finding[T]) {
+	for _, err := range CheckConstraints(rbac) {
+		f := Finding[T]{Message: err.Error()}
+		var sod *SoDError[T]
+		var card *CardinalityError[T]
+		switch {
+		case errors.As(err, &sod):
+			f.Role, f.Related = sod.ID, sod.Roles
+		case errors.As(err, &card):
+			f.Role = card.ID
+		}
+		findings = append(findings, f)
+	}
+	return
+}
+
+func checkExpired[T comparable](rbac *RBAC[T]) (findings []Finding[T]) {
+	for _, e := range rbac.Expired() {
+		findings = append(findings, Finding[T]{
+			Role:    e.ID,
+			Related: []T{e.Target},
+			Message: fmt.Sprintf("%s of %v to %v expired at %s",
+				e.Kind, e.Target, e.ID, e.Period.NotAfter.Format(time.RFC3339)),
+		})
+	}
+	return
+}
@@ -0,0 +1,115 @@
+package gorbac
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	r := New[string]()
+	for _, id := range []string{"admin", "editor", "author", "reader", "empty"} {
+		role := NewRole(id)
+		if id != "empty" {
+			assert(t, role.Assign(NewPermission("permission-"+id)))
+		}
+		assert(t, r.Add(role))
+	}
+	assert(t, r.SetParent("admin", "editor"))
+	assert(t, r.SetParent("editor", "author"))
+	assert(t, r.SetParent("author", "reader"))
+	// admin -> reader is inherited through editor
+	assert(t, r.SetParent("admin", "reader"))
+	// editor has the permission of its ancestor reader
+	assert(t, r.AssignWithin("editor", NewPermission("permission-reader"), Period{}))
+	// a parent pointing at a removed role
+	r.parents["author"]["removed"] = Period{}
+
+	findings := Validate(r)
+	codes := make(map[string]Finding[string])
+	for _, f := range findings {
+		codes[f.Code] = f
+	}
+	if len(findings) != 4 {
+		t.Fatalf("Four findings expected, but %v got", findings)
+	}
+	if f := codes[CodeEmptyRole]; f.Role != "empty" || f.Severity != SeverityWarning {
+		t.Fatalf("Unexpected finding: %v", f)
+	}
+	if f := codes[CodeRedundantEdge]; f.Role != "admin" || f.Related[0] != "reader" {
+		t.Fatalf("Unexpected finding: %v", f)
+	}
+	if f := codes[CodeRedundantGrant]; f.Role != "editor" || f.Related[0] != "permission-reader" {
+		t.Fatalf("Unexpected finding: %v", f)
+	}
+	if f := codes[CodeDanglingParent]; f.Role != "author" || f.Related[0] != "removed" {
+		t.Fatalf("Unexpected finding: %v", f)
+	}
+	if findings[0].Code != CodeDanglingParent || MaxSeverity(findings) != SeverityError {
+		t.Fatal("Errors should be sorted first")
+	}
+
+	text, err := json.Marshal(findings[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(text), `"severity":"error"`) {
+		t.Fatalf("Severity should be serialised by name, but %s got", text)
+	}
+	var f Finding[string]
+	if err := json.Unmarshal(text, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Severity != SeverityError || f.Code != CodeDanglingParent {
+		t.Fatalf("Unexpected finding: %v", f)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New[string]()
+	r.SetClock(func() time.Time { return now })
+	for _, id := range []string{"role-x", "role-y", "role-z"} {
+		assert(t, r.Add(NewRole(id)))
+	}
+	assert(t, r.SetParent("role-x", "role-y"))
+	assert(t, r.SetParent("role-y", "role-x"))
+	assert(t, r.AssignRoleWithin("alice", "role-z", Period{NotAfter: now.Add(-time.Hour)}))
+	assert(t, r.AssignRole("bob", "role-z"))
+	assert(t, r.AssignRoleWithin("carol", "role-z", Period{NotBefore: now.Add(time.Hour)}))
+	assert(t, r.SetLimit("role-z", Limit{Holders: 1}))
+
+	var rules []Rule[string]
+	for _, rule := range DefaultRules[string]() {
+		if rule.Code != CodeEmptyRole {
+			rules = append(rules, rule)
+		}
+	}
+	codes := make(map[string]int)
+	for _, f := range Validate(r, rules...) {
+		codes[f.Code]++
+	}
+	if codes[CodeEmptyRole] != 0 || codes[CodeCircle] != 1 ||
+		codes[CodeConstraint] != 1 || codes[CodeExpired] != 1 {
+		t.Fatalf("Unexpected findings: %v", codes)
+	}
+
+	custom := Rule[string]{
+		Code:     "no-z",
+		Severity: SeverityError,
+		Check: func(r *RBAC[string]) []Finding[string] {
+			if _, _, err := r.Get("role-z"); err == nil {
+				return []Finding[string]{{Role: "role-z", Message: "role-z is forbidden"}}
+			}
+			return nil
+		},
+	}
+	findings := Validate(r, custom)
+	if len(findings) != 1 || findings[0].Code != "no-z" || findings[0].Severity != SeverityError {
+		t.Fatalf("Unexpected findings: %v", findings)
+	}
+	if MaxSeverity[string](nil) != -1 {
+		t.Fatal("No finding should have no severity")
+	}
+}